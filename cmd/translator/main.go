@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/awesoma31/csa-lab4/pkg/translator"
+	"github.com/awesoma31/csa-lab4/pkg/translator/codegen"
 )
 
 func main() {
@@ -25,6 +26,8 @@ func main() {
 		SrcPath: in, OutDir: out,
 		Debug:  dbg,
 		LogDir: "logs",
+
+		Codegen: codegen.Options{BranchCarry: flags.BranchCarry, BoundsCheck: flags.BoundsCheck, HeapSize: flags.HeapSize},
 	}); err != nil {
 		log.Fatal(err)
 	}
@@ -34,12 +37,19 @@ type flags struct {
 	InPath     string
 	OutDirPath string
 	Debug      bool
+
+	BranchCarry bool
+	BoundsCheck bool
+	HeapSize    int
 }
 
 func (f *flags) parseFlags() {
 	flag.StringVar(&f.InPath, "in", "", "source file path")
 	flag.StringVar(&f.OutDirPath, "o", "bin", "directory to save bin files ")
 	flag.BoolVar(&f.Debug, "debug", false, "print dumps to stdout")
+	flag.BoolVar(&f.BranchCarry, "branch-carry", false, "propagate the carry of long arithmetic with branches instead of ADC/SBC")
+	flag.BoolVar(&f.BoundsCheck, "bounds-check", false, "check array indexes at run time, an index out of range stops the machine with a trap")
+	flag.IntVar(&f.HeapSize, "heap-size", codegen.DefaultHeapSize, "bytes reserved for alloc at the end of the data image")
 	flag.Parse()
 
 	if f.InPath == "" {
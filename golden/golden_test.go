@@ -15,8 +15,32 @@ func TestGolden(t *testing.T) {
 		{"cat", "cat"},
 		{"hello_username", "hello_user"},
 		{"sort", "sort"},
+		{"sort_int", "sort_int"},
 		{"alg", "alg"},
 		{"math", "math"},
+		{"functions", "functions"},
+		{"scope", "scope"},
+		{"logic", "logic"},
+		{"truthiness", "truthiness"},
+		{"modulo", "modulo"},
+		{"bitwise", "bitwise"},
+		{"compound", "compound"},
+		{"break_continue", "break_continue"},
+		{"for_loops", "for_loops"},
+		{"types", "types"},
+		{"annotations", "annotations"},
+		{"long_math", "long_math"},
+		{"unsigned", "unsigned"},
+		{"ternary", "ternary"},
+		{"match", "match"},
+		{"bools", "bools"},
+		{"consts", "consts"},
+		{"arrays", "arrays"},
+		{"bounds", "bounds"},
+		{"traps", "traps"},
+		{"stack", "stack"},
+		{"heap", "heap"},
+		{"heap_stack", "heap_stack"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
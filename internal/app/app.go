@@ -13,7 +13,9 @@ import (
 	"github.com/awesoma31/csa-lab4/pkg/machine/io"
 	"github.com/awesoma31/csa-lab4/pkg/machine/logger"
 	"github.com/awesoma31/csa-lab4/pkg/translator/codegen"
+	"github.com/awesoma31/csa-lab4/pkg/translator/diag"
 	"github.com/awesoma31/csa-lab4/pkg/translator/parser"
+	"github.com/awesoma31/csa-lab4/pkg/translator/sema"
 	"github.com/sanity-io/litter"
 	"gopkg.in/yaml.v2"
 )
@@ -74,15 +76,15 @@ func handleSimulate(w http.ResponseWriter, r *http.Request) error {
 		renderResult(w, SimulateResponse{Output: "Nothing to simulate!"})
 		return nil
 	}
-	ast, pErr := parser.Parse(string(src))
+	ast, pErr := parser.ParseFile("src", src)
 	if len(pErr) != 0 {
 		return apiError{Err: "Parsing Error", Status: http.StatusBadRequest, Errors: pErr}
 	}
 
-	cg := codegen.NewCodeGenerator()
-	memI, memD, dbgAsm, cgErr := cg.Generate(ast)
-	if len(cgErr) != 0 {
-		return apiError{Err: "code generation error", Status: http.StatusBadRequest, Errors: cgErr}
+	ast, semErr := sema.Check(ast)
+	if len(semErr) != 0 {
+		source := diag.Source{File: "src", Text: src}
+		return apiError{Err: "semantic error", Status: http.StatusBadRequest, Errors: source.FormatAll(semErr)}
 	}
 
 	if strings.TrimSpace(r.Form.Get("cfg")) == "" {
@@ -91,7 +93,12 @@ func handleSimulate(w http.ResponseWriter, r *http.Request) error {
 	}
 	cfgBytes := []byte(r.Form.Get("cfg"))
 	var cpuCFG *machine.CpuConfig
-	if err := yaml.Unmarshal(cfgBytes, &cpuCFG); err != nil {
+	var tcfg translatorConfig
+	err := yaml.Unmarshal(cfgBytes, &cpuCFG)
+	if err == nil {
+		err = yaml.Unmarshal(cfgBytes, &tcfg)
+	}
+	if err != nil {
 		return apiError{
 			Err:    "invalid yaml config",
 			Status: http.StatusBadRequest,
@@ -99,6 +106,17 @@ func handleSimulate(w http.ResponseWriter, r *http.Request) error {
 		}
 	}
 
+	cg := codegen.NewCodeGenerator()
+	cg.SetSource("src", src)
+	cg.SetOptions(codegen.Options{
+		BoundsCheck: tcfg.Translator.BoundsCheck,
+		HeapSize:    tcfg.Translator.HeapSize,
+	})
+	memI, memD, dbgAsm, cgErr := cg.Generate(ast)
+	if len(cgErr) != 0 {
+		return apiError{Err: "code generation error", Status: http.StatusBadRequest, Errors: cgErr}
+	}
+
 	ioc := io.NewIOController(cpuCFG.Schedule)
 	//TODO: just get the logs
 	lg := logger.New(true, cpuCFG.LogFilePath)
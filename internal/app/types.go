@@ -26,6 +26,15 @@ type SimulateResponse struct {
 	// SymbolTable string `json:"symbol_table"`
 }
 
+// translatorConfig holds the translator switches of a simulation, they are
+// read from the `translator` section of its config, like in the golden tests.
+type translatorConfig struct {
+	Translator struct {
+		BoundsCheck bool `yaml:"bounds_check"`
+		HeapSize    int  `yaml:"heap_size"`
+	} `yaml:"translator"`
+}
+
 type ErrorResponse struct {
 	Error  string   `json:"error"`
 	Status int      `json:"status"`
@@ -13,11 +13,21 @@ import (
 	"github.com/awesoma31/csa-lab4/pkg/machine/io"
 	"github.com/awesoma31/csa-lab4/pkg/machine/logger"
 	"github.com/awesoma31/csa-lab4/pkg/translator"
+	"github.com/awesoma31/csa-lab4/pkg/translator/codegen"
 	"gopkg.in/yaml.v2"
 )
 
 var update = flag.Bool("u", false, "rewrite *.golden files") //
 
+// translatorConfig holds the translator switches of a golden test, they are
+// read from the `translator` section of its config.yaml.
+type translatorConfig struct {
+	Translator struct {
+		BoundsCheck bool `yaml:"bounds_check"`
+		HeapSize    int  `yaml:"heap_size"`
+	} `yaml:"translator"`
+}
+
 func RunGolden(t *testing.T, dir string) {
 	t.Helper()
 
@@ -27,20 +37,30 @@ func RunGolden(t *testing.T, dir string) {
 	logDir := filepath.Join(dir, "logs")
 	goldenOutputPath := filepath.Join(dir, "output.golden")
 
-	_, _, err := translator.Run(translator.Options{
+	raw, err := os.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("cpu config, %s", err.Error())
+	}
+	var tcfg translatorConfig
+	if err := yaml.Unmarshal(raw, &tcfg); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, _, err = translator.Run(translator.Options{
 		SrcPath: src,
 		OutDir:  outDir,
 		LogDir:  logDir,
 		Debug:   false,
+
+		Codegen: codegen.Options{
+			BoundsCheck: tcfg.Translator.BoundsCheck,
+			HeapSize:    tcfg.Translator.HeapSize,
+		},
 	})
 	if err != nil {
 		cwd, _ := os.Getwd()
 		t.Fatalf("translate: %v, \n CWD- %s", err, cwd)
 	}
-	raw, err := os.ReadFile(cfgPath)
-	if err != nil {
-		t.Fatalf("cpu config, %s", err.Error())
-	}
 	var cfg *machine.CpuConfig
 	if err := yaml.Unmarshal(raw, &cfg); err != nil {
 		t.Fatal(err.Error())
@@ -2,28 +2,58 @@ package logutil
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
 	"github.com/awesoma31/csa-lab4/pkg/translator/codegen"
 	"github.com/sanity-io/litter"
 )
 
+// astDump leaves source positions out of AST dumps, they would repeat on every node.
+var astDump = litter.Options{
+	FieldExclusions: regexp.MustCompile(`^(Node|Pos)$`),
+}
+
 func PrintAst(program ast.BlockStmt) {
 	fmt.Println("-------------------AST----------------------")
-	litter.Dump(program)
+	fmt.Println(astDump.Sdump(program))
 }
 
 func PrintSymTable(cg *codegen.CodeGenerator) {
 	fmt.Println("-------------------SymTable--------------------------")
-	fmt.Println("[var_name | addres]")
-	scopeStack := cg.ScopeStack()
+	writeSymTable(os.Stdout, cg)
+}
+
+// writeSymTable writes every scope indented by its nesting depth, symbols sorted by name.
+func writeSymTable(w io.Writer, cg *codegen.CodeGenerator) {
+	_, _ = fmt.Fprintf(w, "[var_name | type | addres]\n")
+	for _, sc := range cg.Scopes() {
+		indent := strings.Repeat("  ", sc.Depth())
+		_, _ = fmt.Fprintf(w, "%s<%s>\n", indent, sc.Name())
+
+		symbols := sc.Symbols()
+		names := make([]string, 0, len(symbols))
+		for name := range symbols {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sym := symbols[name]
+			_, _ = fmt.Fprintf(w, "%s  %s | %s |  %X\n", indent, name, symTypeName(sym.Type), sym.AbsAddress)
+		}
+	}
+}
 
-	for k, v := range scopeStack[0].Symbols() {
-		fmt.Print(k, " | ")
-		fmt.Printf(" %X\n", v.AbsAddress)
+func symTypeName(t ast.Type) string {
+	if t == nil {
+		return "?"
 	}
+	return t.String()
 }
 
 func PrintDataMem(dataMemory []byte) {
@@ -63,7 +93,7 @@ func DumpAst(program ast.BlockStmt, filePath string, debug bool) {
 		return
 	}
 	defer closeFile(file)
-	astString := litter.Sdump(program)
+	astString := astDump.Sdump(program)
 	_, err = fmt.Fprint(file, astString)
 	if err != nil {
 		log.Printf("Error writing AST to file %s: %v", filePath, err)
@@ -82,12 +112,7 @@ func DumpSymTable(cg *codegen.CodeGenerator, filePath string, debug bool) {
 	}
 	defer closeFile(file)
 
-	_, _ = fmt.Fprintf(file, "[var_name | addres]\n")
-	scopeStack := cg.ScopeStack()
-
-	for k, v := range scopeStack[0].Symbols() {
-		_, _ = fmt.Fprintf(file, "%s |  %X\n", k, v.AbsAddress)
-	}
+	writeSymTable(file, cg)
 
 	if debug {
 		PrintSymTable(cg)
@@ -2,8 +2,8 @@ package machine
 
 import (
 	"fmt"
-	"log"
-	"log/slog"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -14,7 +14,7 @@ import (
 )
 
 const (
-	StackSize = 0x100
+	StackSize = 0x100 // default stack size in bytes
 )
 
 var (
@@ -27,6 +27,7 @@ type CpuConfig struct {
 	TickLimit        int            `yaml:"tick_limit"`
 	Schedule         []io.TickEntry `yaml:"schedule"`
 	MaxInterruptions int            `yaml:"max_interruptions"`
+	StackSize        int            `yaml:"stack_size"` // bytes, StackSize if not set
 	Debug            bool           `yaml:"debug"`
 	LogFilePath      string         `yaml:"log_file"`
 
@@ -48,6 +49,7 @@ type CPU struct {
 		PC, IR   uint32
 		savedGPR [18]uint32
 		savedPC  uint32
+		Cause    uint32 // cause of the last trap taken, read by MOV rd, CAUSE
 	}
 	N, Z, V, C     bool
 	savedNZVCFlags uint8
@@ -60,6 +62,13 @@ type CPU struct {
 	Tick             int
 	TickLimit        int
 	halted           bool
+	instrAddr        uint32 // address of the instruction being executed, reported by traps
+	trapPending      bool
+	trapCause        uint32
+	trapMsg          string // report of the pending trap, kept if it stopped the machine
+	stoppedByTrap    bool
+	stackLimit       uint32 // lowest address of the stack area, it ends at StackStart
+	minSP            uint32 // lowest SP reached, the stack high-water mark
 	maxInterruptions int
 
 	log *logger.Logger
@@ -77,9 +86,16 @@ func New(cfg *CpuConfig) *CPU {
 		log:              cfg.Logger,
 	}
 
-	StackStart = uint32(len(c.memD) + StackSize)
+	stackSize := cfg.StackSize
+	if stackSize <= 0 {
+		stackSize = StackSize
+	}
+	c.stackLimit = uint32(len(c.memD))
+	StackStart = c.stackLimit + uint32(stackSize)
+	c.memD = append(c.memD, make([]byte, stackSize)...)
 	c.Reg.GPR[isa.SpReg] = StackStart
-	c.Reg.PC = uint32(c.maxInterruptions)
+	c.minSP = StackStart
+	c.Reg.PC = uint32(c.maxInterruptions) + isa.TrapCount
 
 	c.step = c.fetch()
 	return c
@@ -96,6 +112,11 @@ func (c *CPU) Run() string {
 		}
 
 		finished := c.step(c)
+		if c.trapPending {
+			// the faulting instruction is abandoned
+			c.takeTrap()
+			finished = true
+		}
 
 		if finished {
 			if c.pending && !c.inISR && c.IsIntOn {
@@ -106,13 +127,99 @@ func (c *CPU) Run() string {
 		}
 	}
 
+	out := c.GetFormattedPortOutputs()
+	if c.stoppedByTrap {
+		out = strings.TrimLeft(out+"\ntrap| "+c.trapMsg, "\n")
+	}
 	fmt.Println("ticks", c.Tick)
-	fmt.Println(c.GetFormattedPortOutputs())
-	return c.GetFormattedPortOutputs()
+	fmt.Printf("stack %d/%d bytes\n", c.StackHighWater(), StackStart-c.stackLimit)
+	c.log.Debugf("stack high-water mark %d of %d bytes\n", c.StackHighWater(), StackStart-c.stackLimit)
+	fmt.Println(out)
+	return out
+}
+
+// StackHighWater returns the most bytes the stack held during the run.
+func (c *CPU) StackHighWater() uint32 {
+	return StackStart - c.minSP
+}
+
+// checkPush reports whether PUSH and CALL have room for a word,
+// otherwise it raises the stack overflow trap.
+func (c *CPU) checkPush() bool {
+	sp := c.Reg.GPR[isa.SpReg]
+	if sp < c.stackLimit+4 {
+		c.raiseTrap(isa.TrapStackOverflow, fmt.Sprintf(" SP=%d/0x%X", sp, sp))
+		return false
+	}
+	return true
+}
+
+// pushSP moves SP down a word and updates the high-water mark.
+func (c *CPU) pushSP() {
+	c.Reg.GPR[isa.SpReg] -= 4
+	c.minSP = min(c.minSP, c.Reg.GPR[isa.SpReg])
+}
+
+// checkPop reports whether POP and RET have a word to take,
+// otherwise it raises the stack underflow trap.
+func (c *CPU) checkPop() bool {
+	sp := c.Reg.GPR[isa.SpReg]
+	if uint64(sp)+4 > uint64(StackStart) {
+		c.raiseTrap(isa.TrapStackUnderflow, fmt.Sprintf(" SP=%d/0x%X", sp, sp))
+		return false
+	}
+	return true
+}
+
+// raiseTrap makes the current instruction fault, the trap is taken when the
+// microstep returns; detail is appended to the report.
+func (c *CPU) raiseTrap(cause uint32, detail string) {
+	c.trapPending, c.trapCause = true, cause
+	c.trapMsg = fmt.Sprintf("%s, PC=%d/0x%X%s", isa.GetTrapMnem(cause), c.instrAddr, c.instrAddr, detail)
+	c.log.Debugf("TICK % 4d - TRAP %d: %s\n", c.Tick, cause, c.trapMsg)
+}
+
+// takeTrap enters the handler of the pending trap like an interruption: the state
+// is saved, CAUSE is set and IRET resumes after the faulting instruction.
+// Without a handler the machine stops, except on division by zero, which only
+// sets V. A trap inside a handler stops the machine too.
+func (c *CPU) takeTrap() {
+	c.trapPending = false
+	vec := c.memI[uint32(c.maxInterruptions)+c.trapCause]
+	switch {
+	case vec == 0 && c.trapCause == isa.TrapDivZero:
+		return
+	case vec == 0:
+		c.stopByTrap()
+		return
+	case c.inISR:
+		c.trapMsg += " (in a handler)"
+		c.stopByTrap()
+		return
+	}
+	c.log.Debugf("------------Entering Trap %d------------\n", c.trapCause)
+	c.Reg.savedPC = c.Reg.PC
+	c.SaveNZVC()
+	c.SaveGPRValues()
+	c.Reg.Cause = c.trapCause
+	c.Reg.PC = vec
+	c.inISR = true
+}
+
+// stopByTrap halts the machine, the trap is reported after the port outputs.
+func (c *CPU) stopByTrap() {
+	c.log.Debugf("TICK % 4d - stopped by trap: %s\n", c.Tick, c.trapMsg)
+	c.stoppedByTrap = true
+	c.halted = true
 }
 
 func (c *CPU) fetch() microStep {
 	return func(c *CPU) bool {
+		c.instrAddr = c.Reg.PC
+		if c.Reg.PC >= uint32(len(c.memI)) {
+			c.raiseTrap(isa.TrapMemFault, " (fetch)")
+			return true
+		}
 		c.Reg.IR = c.memI[c.Reg.PC]
 		c.Reg.PC++
 		op, mode, rd, rs1, rs2 := decoder.Dec(c.Reg.IR)
@@ -122,9 +229,8 @@ func (c *CPU) fetch() microStep {
 			fmt.Sprintf("TICK % 4d @ 0x%08X -  %v %v; PC++ | %v\n", c.Tick, c.Reg.IR, isa.GetOpMnemonic(op), isa.GetAMnemonic(mode), c.ReprPC()),
 		)
 		if f == nil {
-			slog.Error("unknown instruction", "PC", c.Reg.PC-1, "IR", c.Reg.IR)
-			log.Fatal()
-			return false
+			c.raiseTrap(isa.TrapIllegal, fmt.Sprintf(" IR=0x%08X", c.Reg.IR))
+			return true
 		}
 		c.step = f(rd, rs1, rs2)
 		return false
@@ -134,7 +240,10 @@ func (c *CPU) fetch() microStep {
 func (c *CPU) GetFormattedPortOutputs() string {
 	var sb strings.Builder
 
-	for port, buf := range c.Ioc.OutBufAll() {
+	outBuf := c.Ioc.OutBufAll()
+	// ports in a fixed order, so the output does not depend on map iteration
+	for _, port := range slices.Sorted(maps.Keys(outBuf)) {
+		buf := outBuf[port]
 		if len(buf) == 0 {
 			continue
 		}
@@ -193,6 +302,11 @@ func (c *CPU) GetFormattedPortOutputs() string {
 }
 
 func (c *CPU) raiseIRQ(vec isa.Register) {
+	if int(vec) >= c.maxInterruptions {
+		// e.g. the long port: the value is latched and read by the program, no handler runs
+		c.log.Debugf("no interruption vector %v, value latched on the port\n", vec)
+		return
+	}
 	if c.inISR || c.pending {
 		c.log.Debugf("interruption ignored, either in one or one is already pending, %v\n", vec)
 		return
@@ -269,12 +383,14 @@ func (c *CPU) ReprRegVal(r isa.Register) any {
 	return fmt.Sprintf("%v=%d/0x%X", isa.GetRegMnem(r), c.Reg.GPR[r], c.Reg.GPR[r])
 }
 
-func (c *CPU) ensureDataSize(last uint32) {
-	if last < uint32(len(c.memD)) {
-		return
+// checkData reports whether n bytes at addr are inside data memory,
+// otherwise it raises a memory fault.
+func (c *CPU) checkData(addr, n uint32) bool {
+	if uint64(addr)+uint64(n) <= uint64(len(c.memD)) {
+		return true
 	}
-	need := last - uint32(len(c.memD)) + 1
-	c.memD = append(c.memD, make([]byte, need)...)
+	c.raiseTrap(isa.TrapMemFault, fmt.Sprintf(" address=%d/0x%X", addr, addr))
+	return false
 }
 
 func (c *CPU) DumpState() string {
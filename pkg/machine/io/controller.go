@@ -8,7 +8,7 @@ import (
 
 type Controller struct {
 	Sched    map[int]Input
-	portsVal map[isa.Register]uint32
+	portsVal map[isa.Register]uint64
 	outBuf   map[isa.Register][]uint32
 }
 
@@ -23,7 +23,7 @@ func NewIOController(entries []TickEntry) *Controller {
 	}
 	return &Controller{
 		Sched:    m,
-		portsVal: make(map[isa.Register]uint32),
+		portsVal: make(map[isa.Register]uint64),
 		outBuf:   make(map[isa.Register][]uint32),
 	}
 }
@@ -35,12 +35,17 @@ func (ioc *Controller) CheckTick(tick int) (bool, isa.Register) {
 	}
 
 	port := isa.Register(inp.IrqNumber)
-	ioc.portsVal[port] = toUint32(inp.Value)
+	ioc.portsVal[port] = toUint64(inp.Value)
 	delete(ioc.Sched, tick)
 	return true, port
 }
 
 func (ioc *Controller) ReadPort(p isa.Register) uint32 {
+	return uint32(ioc.portsVal[p])
+}
+
+// ReadPortLong returns the full 64-bit value of a port, used by the long port.
+func (ioc *Controller) ReadPortLong(p isa.Register) uint64 {
 	return ioc.portsVal[p]
 }
 
@@ -60,18 +65,18 @@ func (ioc *Controller) OutputAll() []uint32 {
 	return all
 }
 
-func toUint32(v any) uint32 {
+func toUint64(v any) uint64 {
 	switch t := v.(type) {
 	case int, int8, int16, int32, int64:
-		return uint32(reflect.ValueOf(t).Int())
+		return uint64(reflect.ValueOf(t).Int())
 	case uint, uint16, uint32, uint64:
-		return uint32(reflect.ValueOf(t).Uint())
+		return reflect.ValueOf(t).Uint()
 	case string:
 		if len(t) > 0 {
-			return uint32(t[0]) // 1 char
+			return uint64(t[0]) // 1 char
 		}
 	case byte:
-		return uint32(t)
+		return uint64(t)
 	}
 	return 0
 }
@@ -1,6 +1,7 @@
 package machine
 
 import (
+	"fmt"
 	"log/slog"
 
 	"github.com/awesoma31/csa-lab4/pkg/translator/isa"
@@ -29,11 +30,17 @@ func init() {
 	ucode[isa.OpMov][isa.MvByteRegIndToReg] = uMovByteRegIndReg
 	ucode[isa.OpMov][isa.MvRegLowToMem] = uMovRegByteToMem
 	ucode[isa.OpMov][isa.MvLowRegToRegInd] = uMovByteRegToRegInd
+	ucode[isa.OpMov][isa.MvRegToRegInd] = uMovRegToRegInd
+	ucode[isa.OpMov][isa.MvCauseReg] = uMovCauseReg
 
 	// JUMP BRENCH
 	ucode[isa.OpCmp][isa.RegReg] = uCmpRR
+	ucode[isa.OpBound][isa.RegReg] = uBoundRR
 
 	ucode[isa.OpJmp][isa.JAbsAddr] = uJump
+	ucode[isa.OpJmp][isa.JReg] = uJumpReg
+	ucode[isa.OpCall][isa.JAbsAddr] = uCall
+	ucode[isa.OpRet][isa.NoOperands] = uRet
 	ucode[isa.OpJe][isa.JAbsAddr] = uJE
 	ucode[isa.OpJne][isa.JAbsAddr] = uJNE
 	ucode[isa.OpJg][isa.JAbsAddr] = uJG
@@ -42,6 +49,11 @@ func init() {
 	ucode[isa.OpJle][isa.JAbsAddr] = uJLE
 	ucode[isa.OpJcc][isa.JAbsAddr] = uJCC
 	ucode[isa.OpJcs][isa.JAbsAddr] = uJCS
+	// unsigned, after CMP a, b: C = a < b
+	ucode[isa.OpJa][isa.JAbsAddr] = uJcond("JA", func(c *CPU) bool { return !c.C && !c.Z })
+	ucode[isa.OpJb][isa.JAbsAddr] = uJcond("JB", func(c *CPU) bool { return c.C })
+	ucode[isa.OpJae][isa.JAbsAddr] = uJcond("JAE", func(c *CPU) bool { return !c.C })
+	ucode[isa.OpJbe][isa.JAbsAddr] = uJcond("JBE", func(c *CPU) bool { return c.C || c.Z })
 
 	//stack
 	ucode[isa.OpPush][isa.SingleRegMode] = uPushReg
@@ -54,10 +66,24 @@ func init() {
 	ucode[isa.OpSub][isa.MathRIR] = uSubRIR
 	ucode[isa.OpMul][isa.MathRRR] = uMulRRR
 	ucode[isa.OpDiv][isa.MathRRR] = uDivRRR
+	ucode[isa.OpRem][isa.MathRRR] = uRemRRR
+	ucode[isa.OpMul][isa.MathRIR] = uMulRIR
+	ucode[isa.OpDiv][isa.MathRIR] = uDivRIR
+	ucode[isa.OpRem][isa.MathRIR] = uRemRIR
+	ucode[isa.OpAdc][isa.MathRRR] = uAdcRRR
+	ucode[isa.OpAdc][isa.MathRIR] = uAdcRIR
+	ucode[isa.OpSbc][isa.MathRRR] = uSbcRRR
+	ucode[isa.OpSbc][isa.MathRIR] = uSbcRIR
 
 	// LOGICAL
 	ucode[isa.OpAnd][isa.ImmReg] = uAndIR
 	ucode[isa.OpAnd][isa.RegReg] = uAndRR
+	ucode[isa.OpOr][isa.RegReg] = uOrRR
+	ucode[isa.OpXor][isa.RegReg] = uXorRR
+	ucode[isa.OpNot][isa.RegReg] = uNotRR
+	ucode[isa.OpShl][isa.MathRRR] = uShlRRR
+	ucode[isa.OpShr][isa.MathRRR] = uShrRRR
+	ucode[isa.OpSar][isa.MathRRR] = uSarRRR
 
 	// IO
 	ucode[isa.OpOut][isa.ByteM] = uOutCh
@@ -65,6 +91,7 @@ func init() {
 	ucode[isa.OpOut][isa.LongM] = uOutL
 	ucode[isa.OpIn][isa.ByteM] = uInCh
 	ucode[isa.OpIn][isa.DigitM] = uInD
+	ucode[isa.OpIn][isa.LongM] = uInL
 
 	ucode[isa.OpIRet][isa.NoOperands] = uIRet
 	ucode[isa.OpIntOn][isa.NoOperands] = uIntOn
@@ -120,6 +147,36 @@ func uInD(_, _, _ isa.Register) microStep {
 	}
 }
 
+// uInL stores the 64-bit value of the long port to mem[RInData], the low word first,
+// the same layout OUT in Long mode reads from mem[ROutAddr]
+func uInL(_, _, _ isa.Register) microStep {
+	stage := 0
+	writeStage := 1
+	var val uint64
+	return func(c *CPU) bool {
+		switch stage {
+		case 0:
+			val = c.Ioc.ReadPortLong(isa.PortL)
+			c.Reg.GPR[isa.RF1] = c.Reg.GPR[isa.RInData]
+			c.Reg.GPR[isa.RF2] = uint32(val)
+			c.log.Debugf("TICK % 4d - %v<-%v; %v <- %v long(lo) (%d/0x%X) | %v\n", c.Tick, isa.GetRegMnem(isa.RF1), isa.GetRegMnem(isa.RInData), isa.GetRegMnem(isa.RF2), isa.GetPortMnem(isa.PortL), int64(val), val, c.ReprRegVal(isa.RF1))
+			stage++
+		case 1:
+			if write32(c, &writeStage, isa.RF1, isa.RF2) {
+				c.Reg.GPR[isa.RF2] = uint32(val >> 32)
+				c.log.Debugf("TICK % 4d - %v <- %v long(hi) | %v\n", c.Tick, isa.GetRegMnem(isa.RF2), isa.GetPortMnem(isa.PortL), c.ReprRegVal(isa.RF2))
+				writeStage = 1
+				stage++
+			}
+		case 2:
+			if write32(c, &writeStage, isa.RF1, isa.RF2) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 func uOutCh(_, _, _ isa.Register) microStep {
 	return func(c *CPU) bool {
 		port := isa.PortCh
@@ -184,6 +241,18 @@ func uCmpRR(_, rs1, rs2 isa.Register) microStep {
 	}
 }
 
+// uBoundRR raises the bounds trap unless rs1 < rs2 unsigned.
+func uBoundRR(_, rs1, rs2 isa.Register) microStep {
+	return func(c *CPU) bool {
+		index, length := c.Reg.GPR[rs1], c.Reg.GPR[rs2]
+		c.log.Debugf("TICK % 4d - BOUND %v, %v | %v %v\n", c.Tick, isa.GetRegMnem(rs1), isa.GetRegMnem(rs2), c.ReprRegVal(rs1), c.ReprRegVal(rs2))
+		if index >= length {
+			c.raiseTrap(isa.TrapBounds, fmt.Sprintf(" index=%d length=%d", int32(index), length))
+		}
+		return true
+	}
+}
+
 func uJCC(_, _, _ isa.Register) microStep {
 	stage := 0
 	r := isa.RF2
@@ -236,6 +305,73 @@ func uJump(_, _, _ isa.Register) microStep {
 		return true
 	}
 }
+
+// uJumpReg jumps to the address in rs1
+func uJumpReg(_, rs1, _ isa.Register) microStep {
+	return func(c *CPU) bool {
+		c.Reg.PC = c.Reg.GPR[rs1]
+		c.log.Debugf("TICK % 4d - PC<-%v | %v\n", c.Tick, isa.GetRegMnem(rs1), c.ReprPC())
+		return true
+	}
+}
+
+// uCall pushes the address of the next instruction and jumps to memI[PC]
+func uCall(_, _, _ isa.Register) microStep {
+	stage := 0
+	writeStage := 1
+	return func(c *CPU) bool {
+		switch stage {
+		case 0:
+			c.Reg.GPR[isa.RF1] = c.memI[c.Reg.PC]
+			c.log.Debugf("TICK % 4d - %v<-memI[0x%X]; PC++ | %v\n", c.Tick, isa.GetRegMnem(isa.RF1), c.Reg.PC, c.ReprRegVal(isa.RF1))
+			c.Reg.PC++
+			stage++
+		case 1:
+			if !c.checkPush() {
+				return true
+			}
+			c.Reg.GPR[isa.RF2] = c.Reg.PC
+			c.Reg.PC = c.Reg.GPR[isa.RF1]
+			c.log.Debugf("TICK % 4d - %v<-PC; PC<-%v | %v %v\n", c.Tick, isa.GetRegMnem(isa.RF2), isa.GetRegMnem(isa.RF1), c.ReprRegVal(isa.RF2), c.ReprPC())
+			stage++
+		case 2:
+			c.pushSP()
+			c.Reg.GPR[isa.RF1] = c.Reg.GPR[isa.SpReg]
+			c.log.Debugf("TICK % 4d - SP=SP-4; %v=%v | %v\n", c.Tick, isa.GetRegMnem(isa.RF1), isa.GetRegMnem(isa.SpReg), c.ReprRegVal(isa.SpReg))
+			stage++
+		case 3:
+			if write32(c, &writeStage, isa.RF1, isa.RF2) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// uRet pops the return address pushed by CALL into PC
+func uRet(_, _, _ isa.Register) microStep {
+	stage := 0
+	return func(c *CPU) bool {
+		switch stage {
+		case 0:
+			if !c.checkPop() {
+				return true
+			}
+			c.Reg.GPR[isa.RF1] = c.Reg.GPR[isa.SpReg]
+			c.log.Debugf("TICK % 4d - %v<-%v | %v\n", c.Tick, isa.GetRegMnem(isa.RF1), isa.GetRegMnem(isa.SpReg), c.ReprRegVal(isa.RF1))
+			stage++
+		case 1, 2, 3, 4, 5:
+			if read32(c, &stage, isa.RF1, isa.RF2) {
+				c.Reg.GPR[isa.SpReg] += 4
+				c.Reg.PC = c.Reg.GPR[isa.RF2]
+				c.log.Debugf("TICK % 4d - SP=SP+4; PC<-%v | %v %v\n", c.Tick, isa.GetRegMnem(isa.RF2), c.ReprRegVal(isa.SpReg), c.ReprPC())
+				return true
+			}
+		}
+		return false
+	}
+}
+
 func uJE(_, _, _ isa.Register) microStep {
 	stage := 0
 	r := isa.RF2
@@ -369,6 +505,32 @@ func uJLE(_, _, _ isa.Register) microStep {
 	}
 }
 
+// uJcond fetches the target into RF2 and jumps to it if taken reports true for the flags
+func uJcond(name string, taken func(c *CPU) bool) func(_, _, _ isa.Register) microStep {
+	return func(_, _, _ isa.Register) microStep {
+		stage := 0
+		r := isa.RF2
+		return func(c *CPU) bool {
+			switch stage {
+			case 0:
+				c.Reg.GPR[r] = c.memI[c.Reg.PC]
+				c.log.Debugf("TICK % 4d - %v<-memI[0x%X]; PC++ | %v\n", c.Tick, isa.GetRegMnem(r), c.Reg.PC, c.ReprRegVal(r))
+				c.Reg.PC++
+				stage++
+			case 1:
+				if taken(c) {
+					c.Reg.PC = c.Reg.GPR[r]
+					c.log.Debugf("TICK % 4d - %v taken → PC<-%v | %v\n", c.Tick, name, isa.GetRegMnem(r), c.ReprPC())
+					return true
+				}
+				c.log.Debugf("TICK % 4d - %v not taken | %v %v\n", c.Tick, name, c.ReprPC(), c.ReprFlags())
+				return true
+			}
+			return false
+		}
+	}
+}
+
 func uAndIR(rd, rs1, _ isa.Register) microStep {
 	stage := 0
 	return func(c *CPU) bool {
@@ -388,11 +550,102 @@ func uAndIR(rd, rs1, _ isa.Register) microStep {
 }
 func uAndRR(rd, rs1, rs2 isa.Register) microStep {
 	return func(c *CPU) bool {
-		c.Reg.GPR[rd] = c.Reg.GPR[rs1] & c.Reg.GPR[rs2]
-		c.log.Debugf("TICK % 4d - %v<-%v & %v | %v\n", c.Tick, isa.GetRegMnem(rd), isa.GetRegMnem(rs1), isa.GetRegMnem(rs2), c.ReprRegVal(rd))
+		LogicRR(c, rd, rs1, rs2, isa.OpAnd)
+		return true
+	}
+}
+func uOrRR(rd, rs1, rs2 isa.Register) microStep {
+	return func(c *CPU) bool {
+		LogicRR(c, rd, rs1, rs2, isa.OpOr)
 		return true
 	}
 }
+func uXorRR(rd, rs1, rs2 isa.Register) microStep {
+	return func(c *CPU) bool {
+		LogicRR(c, rd, rs1, rs2, isa.OpXor)
+		return true
+	}
+}
+func uNotRR(rd, rs1, _ isa.Register) microStep {
+	return func(c *CPU) bool {
+		LogicRR(c, rd, rs1, rs1, isa.OpNot)
+		return true
+	}
+}
+func uShlRRR(rd, rs1, rs2 isa.Register) microStep {
+	return func(c *CPU) bool {
+		LogicRR(c, rd, rs1, rs2, isa.OpShl)
+		return true
+	}
+}
+func uShrRRR(rd, rs1, rs2 isa.Register) microStep {
+	return func(c *CPU) bool {
+		LogicRR(c, rd, rs1, rs2, isa.OpShr)
+		return true
+	}
+}
+func uSarRRR(rd, rs1, rs2 isa.Register) microStep {
+	return func(c *CPU) bool {
+		LogicRR(c, rd, rs1, rs2, isa.OpSar)
+		return true
+	}
+}
+
+// logicOpLu maps logic and shift opcodes to the operator shown in the log.
+var logicOpLu = map[uint32]string{
+	isa.OpAnd: "&",
+	isa.OpOr:  "|",
+	isa.OpXor: "^",
+	isa.OpShl: "<<",
+	isa.OpShr: ">>>",
+	isa.OpSar: ">>",
+}
+
+// LogicRR performs a bitwise or shift operation and updates the flags:
+// N and Z from the result, C holds the last bit shifted out (0 for non-shifts), V is cleared.
+// Shift amount is taken modulo 32.
+func LogicRR(c *CPU, rd, rs1, rs2 isa.Register, opc uint32) {
+	a := c.Reg.GPR[rs1]
+	b := c.Reg.GPR[rs2]
+	n := b & 31
+
+	var res uint32
+	carry := false
+	switch opc {
+	case isa.OpAnd:
+		res = a & b
+	case isa.OpOr:
+		res = a | b
+	case isa.OpXor:
+		res = a ^ b
+	case isa.OpNot:
+		res = ^a
+	case isa.OpShl:
+		res = a << n
+		carry = n > 0 && a&(1<<(32-n)) != 0
+	case isa.OpShr:
+		res = a >> n
+		carry = n > 0 && a&(1<<(n-1)) != 0
+	case isa.OpSar:
+		res = uint32(int32(a) >> n)
+		carry = n > 0 && a&(1<<(n-1)) != 0
+	default:
+		slog.Error("UNKNOWN LOGIC OP", "op", isa.GetOpMnemonic(opc))
+		return
+	}
+
+	c.Reg.GPR[rd] = res
+	c.N = res&0x8000_0000 != 0
+	c.Z = res == 0
+	c.V = false
+	c.C = carry
+
+	if opc == isa.OpNot {
+		c.log.Debugf("TICK % 4d - %v<-~%v | %v %v\n", c.Tick, isa.GetRegMnem(rd), isa.GetRegMnem(rs1), c.ReprRegVal(rd), c.ReprFlags())
+		return
+	}
+	c.log.Debugf("TICK % 4d - %v<-%v%v%v | %v %v\n", c.Tick, isa.GetRegMnem(rd), isa.GetRegMnem(rs1), logicOpLu[opc], isa.GetRegMnem(rs2), c.ReprRegVal(rd), c.ReprFlags())
+}
 
 func uAddRRR(rd, rs1, rs2 isa.Register) microStep {
 	return func(c *CPU) bool {
@@ -457,11 +710,55 @@ func uDivRRR(rd, rs1, rs2 isa.Register) microStep {
 		return true
 	}
 }
+func uRemRRR(rd, rs1, rs2 isa.Register) microStep {
+	return func(c *CPU) bool {
+		MathRRR(c, rd, rs1, rs2, isa.OpRem)
+		c.log.Debugf("TICK % 4d - %v<-%v%%%v | %v\n", c.Tick, isa.GetRegMnem(rd), isa.GetRegMnem(rs1), isa.GetRegMnem(rs2), c.ReprRegVal(rd))
+		return true
+	}
+}
+func uAdcRRR(rd, rs1, rs2 isa.Register) microStep {
+	return func(c *CPU) bool {
+		MathRRR(c, rd, rs1, rs2, isa.OpAdc)
+		return true
+	}
+}
+func uSbcRRR(rd, rs1, rs2 isa.Register) microStep {
+	return func(c *CPU) bool {
+		MathRRR(c, rd, rs1, rs2, isa.OpSbc)
+		return true
+	}
+}
+func uMulRIR(rd, rs1, _ isa.Register) microStep { return mathRIR(rd, rs1, isa.OpMul) }
+func uDivRIR(rd, rs1, _ isa.Register) microStep { return mathRIR(rd, rs1, isa.OpDiv) }
+func uRemRIR(rd, rs1, _ isa.Register) microStep { return mathRIR(rd, rs1, isa.OpRem) }
+func uAdcRIR(rd, rs1, _ isa.Register) microStep { return mathRIR(rd, rs1, isa.OpAdc) }
+func uSbcRIR(rd, rs1, _ isa.Register) microStep { return mathRIR(rd, rs1, isa.OpSbc) }
+
+// mathRIR fetches the immediate operand into RF1, then does rd <- rs1 opc RF1
+func mathRIR(rd, rs1 isa.Register, opc uint32) microStep {
+	stage := 0
+	r := isa.RF1
+	return func(c *CPU) bool {
+		switch stage {
+		case 0:
+			c.Reg.GPR[r] = c.memI[c.Reg.PC]
+			c.log.Debugf("TICK % 4d - %v<-memI[0x%X]; PC++ | %v\n", c.Tick, isa.GetRegMnem(r), c.Reg.PC, c.ReprRegVal(r))
+			c.Reg.PC++
+			stage++
+		case 1:
+			MathRRR(c, rd, rs1, r, opc)
+			return true
+		}
+		return false
+	}
+}
 
 func MathRRR(c *CPU, rd, rs1, rs2 isa.Register, opc uint32) {
 	a := c.Reg.GPR[rs1]
 	b := c.Reg.GPR[rs2]
 
+	carry := c.C
 	clrF := func() { c.N, c.Z, c.V, c.C = false, false, false, false }
 
 	switch opc {
@@ -487,6 +784,38 @@ func MathRRR(c *CPU, rd, rs1, rs2 isa.Register, opc uint32) {
 		c.C = a >= b
 		c.V = ((a^b)&0x8000_0000 != 0) && ((a^res)&0x8000_0000 != 0)
 
+	case isa.OpAdc:
+		// like ADD with the carry of the previous operation added in
+		var cin uint64
+		if carry {
+			cin = 1
+		}
+		res64 := uint64(a) + uint64(b) + cin
+		res := uint32(res64)
+
+		c.Reg.GPR[rd] = res
+		clrF()
+		c.N = res&0x8000_0000 != 0
+		c.Z = res == 0
+		c.C = res64 > 0xFFFF_FFFF
+		c.V = ((a^b)&0x8000_0000 == 0) && ((a^res)&0x8000_0000 != 0)
+
+	case isa.OpSbc:
+		// C set by SUB means "no borrow", so a clear C subtracts one more
+		var borrow uint64
+		if !carry {
+			borrow = 1
+		}
+		res64 := uint64(a) - uint64(b) - borrow
+		res := uint32(res64)
+
+		c.Reg.GPR[rd] = res
+		clrF()
+		c.N = res&0x8000_0000 != 0
+		c.Z = res == 0
+		c.C = uint64(a) >= uint64(b)+borrow
+		c.V = ((a^b)&0x8000_0000 != 0) && ((a^res)&0x8000_0000 != 0)
+
 	case isa.OpMul:
 		prod := int64(int32(a)) * int64(int32(b))
 		res := uint32(prod)
@@ -501,6 +830,7 @@ func MathRRR(c *CPU, rd, rs1, rs2 isa.Register, opc uint32) {
 		clrF()
 		if b == 0 {
 			c.V = true
+			c.raiseTrap(isa.TrapDivZero, "")
 			return
 		}
 		quot := int32(a) / int32(b)
@@ -510,6 +840,21 @@ func MathRRR(c *CPU, rd, rs1, rs2 isa.Register, opc uint32) {
 		c.N = res&0x8000_0000 != 0
 		c.Z = res == 0
 
+	case isa.OpRem:
+		// the remainder takes the sign of the dividend, as in Go and C
+		clrF()
+		if b == 0 {
+			c.V = true
+			c.raiseTrap(isa.TrapDivZero, "")
+			return
+		}
+		rem := int32(a) % int32(b)
+		res := uint32(rem)
+
+		c.Reg.GPR[rd] = res
+		c.N = res&0x8000_0000 != 0
+		c.Z = res == 0
+
 	default:
 		slog.Error("UNKNOWN ALU OP", "op", isa.GetOpMnemonic(opc))
 	}
@@ -519,6 +864,9 @@ func MathRRR(c *CPU, rd, rs1, rs2 isa.Register, opc uint32) {
 		isa.OpSub: "-",
 		isa.OpMul: "*",
 		isa.OpDiv: "/",
+		isa.OpRem: "%",
+		isa.OpAdc: "+C+",
+		isa.OpSbc: "-!C-",
 	}
 
 	c.log.Debugf("TICK % 4d - %v<-%v%v%v | %v %v\n", c.Tick, isa.GetRegMnem(rd), isa.GetRegMnem(rs1), aluOpLu[opc], isa.GetRegMnem(rs2), c.ReprRegVal(rd), c.ReprFlags())
@@ -530,6 +878,9 @@ func uPopReg(rd, _, _ isa.Register) microStep {
 	return func(c *CPU) bool {
 		switch stage {
 		case 0:
+			if !c.checkPop() {
+				return true
+			}
 			c.Reg.GPR[r] = c.Reg.GPR[isa.SpReg]
 			c.log.Debugf("TICK % 4d - %v<-%v | %v", c.Tick, isa.GetRegMnem(r), isa.GetRegMnem(isa.SpReg), c.ReprRegVal(r))
 			stage++
@@ -552,7 +903,10 @@ func uPushReg(_, rs1, _ isa.Register) microStep {
 	return func(c *CPU) bool {
 		switch stage {
 		case 0:
-			c.Reg.GPR[isa.SpReg] -= 4
+			if !c.checkPush() {
+				return true
+			}
+			c.pushSP()
 			c.log.Debugf("TICK % 4d - SP=SP-4 | %v\n", c.Tick, c.ReprRegVal(isa.SpReg))
 			stage++
 		case 1:
@@ -606,7 +960,9 @@ func uMovRegIndReg(rd, rs1, _ isa.Register) microStep {
 func uMovByteRegIndReg(rd, rs1, _ isa.Register) microStep {
 	return func(c *CPU) bool {
 		addr := c.Reg.GPR[rs1]
-		c.ensureDataSize(addr)
+		if !c.checkData(addr, 1) {
+			return true
+		}
 		c.Reg.GPR[rd] = uint32(c.memD[addr])
 		c.log.Debugf("TICK % 4d - %s <- memD[%X] | %v\n", c.Tick, isa.GetRegMnem(rd), addr, c.ReprRegVal(rd))
 		return true
@@ -625,7 +981,9 @@ func uMovRegByteToMem(_, rs1, _ isa.Register) microStep {
 		case 1:
 			addr := c.Reg.GPR[r]
 			val := byte(c.Reg.GPR[rs1] & 0xFF)
-			c.ensureDataSize(addr)
+			if !c.checkData(addr, 1) {
+				return true
+			}
 			c.memD[addr] = val
 			c.log.Debugf("TICK % 4d - memD[0x%X] <- %s(byte) = 0x%02X\n", c.Tick, addr, isa.GetRegMnem(rs1), val)
 			return true
@@ -637,13 +995,33 @@ func uMovByteRegToRegInd(rd, rs1, _ isa.Register) microStep {
 	return func(c *CPU) bool {
 		val := byte(c.Reg.GPR[rs1] & 0xFF)
 		addr := c.Reg.GPR[rd]
-		c.ensureDataSize(addr)
+		if !c.checkData(addr, 1) {
+			return true
+		}
 		c.memD[addr] = val
 		c.log.Debugf("TICK % 4d - memD[0x%X] <- %s(byte); mem[%s]<-%s(byte) = 0x%02X\n", c.Tick, addr, isa.GetRegMnem(rs1), isa.GetRegMnem(rd), isa.GetRegMnem(rs1), val)
 
 		return true
 	}
 }
+func uMovRegToRegInd(rd, rs1, _ isa.Register) microStep {
+	stage := 0
+	r := isa.RF1
+	return func(c *CPU) bool {
+		switch stage {
+		case 0:
+			c.Reg.GPR[r] = c.Reg.GPR[rd]
+			c.log.Debugf("TICK % 4d - %v<-%v | %v\n", c.Tick, isa.GetRegMnem(r), isa.GetRegMnem(rd), c.ReprRegVal(r))
+			stage++
+		case 1, 2, 3, 4:
+			if write32(c, &stage, r, rs1) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 func uMovRegMem(_, rs1, _ isa.Register) microStep {
 	stage := 0
 	r := isa.RF1
@@ -688,6 +1066,13 @@ func uMovImmReg(rd, _, _ isa.Register) microStep {
 		return true
 	}
 }
+func uMovCauseReg(rd, _, _ isa.Register) microStep {
+	return func(c *CPU) bool {
+		c.Reg.GPR[rd] = c.Reg.Cause
+		c.log.Debugf("TICK % 4d - %v<-CAUSE | %v\n", c.Tick, isa.GetRegMnem(rd), c.ReprRegVal(rd))
+		return true
+	}
+}
 func uMovRegReg(rd, rs1, _ isa.Register) microStep {
 	return func(c *CPU) bool {
 		c.Reg.GPR[rd] = c.Reg.GPR[rs1]
@@ -698,8 +1083,11 @@ func uMovRegReg(rd, rs1, _ isa.Register) microStep {
 
 // записывает 32-битное значение little-endian по адресу в regWithAddr
 // возвращает true, когда все 4 байта записаны
+// все 4 байта проверяются до записи первого, слово за границей памяти не записывается частично
 func write32(c *CPU, stage *int, regWithAddr isa.Register, regSource isa.Register) bool {
-	c.ensureDataSize(c.Reg.GPR[regWithAddr] + 3)
+	if *stage == 1 && !c.checkData(c.Reg.GPR[regWithAddr], 4) {
+		return true
+	}
 
 	val := c.Reg.GPR[regSource]
 	switch *stage {
@@ -727,8 +1115,12 @@ func write32(c *CPU, stage *int, regWithAddr isa.Register, regSource isa.Registe
 	return false
 }
 
+// читает 32-битное значение little-endian по адресу в regWithAddr,
+// все 4 байта проверяются до чтения первого
 func read32(c *CPU, stage *int, regWithAddr isa.Register, regToStoreTo isa.Register) bool {
-	c.ensureDataSize(c.Reg.GPR[regWithAddr] + 3)
+	if *stage == 1 && !c.checkData(c.Reg.GPR[regWithAddr], 4) {
+		return true
+	}
 	switch *stage {
 	case 1:
 		c.Reg.GPR[regToStoreTo] = uint32(c.memD[c.Reg.GPR[regWithAddr]])
@@ -1,11 +1,16 @@
 package ast
 
+import "github.com/awesoma31/csa-lab4/pkg/translator/lexer"
+
 type Stmt interface {
 	stmt()
+	Position() lexer.Pos
 }
 
 type Expr interface {
 	expr()
+	Position() lexer.Pos
+	StaticType() Type
 }
 
 type Type interface {
@@ -13,6 +18,23 @@ type Type interface {
 	String() string
 }
 
+// Node is embedded into every statement and expression,
+// Pos is the position of the first token of the node in the source.
+// Type is the static type of an expression, it is set by the semantic pass (see package sema)
+// and stays nil for statements and for programs that were not checked.
+type Node struct {
+	Pos  lexer.Pos
+	Type Type
+}
+
+func (n Node) Position() lexer.Pos {
+	return n.Pos
+}
+
+func (n Node) StaticType() Type {
+	return n.Type
+}
+
 // func ExpectExpr[T Expr](expr Expr) T {
 // 	return helpers.ExpectType[T](expr)
 // }
@@ -9,44 +9,85 @@ import (
 // --------------------
 
 type NumberExpr struct {
+	Node
 	Value int32
 }
 
 func (n NumberExpr) expr() {}
 
 type LongNumberExpr struct {
+	Node
 	Value int64
 }
 
 func (n LongNumberExpr) expr() {}
 
 type StringExpr struct {
+	Node
 	Value string
 }
 
 func (n StringExpr) expr() {}
 
+// BoolExpr is `true` or `false`, stored as 1 or 0.
+type BoolExpr struct {
+	Node
+	Value bool
+}
+
+func (n BoolExpr) expr() {}
+
 type SymbolExpr struct {
+	Node
 	Value string
 }
 
 func (n SymbolExpr) expr() {}
 
-type ReadChExpr struct{}
+type ReadChExpr struct {
+	Node
+}
 
 func (n ReadChExpr) expr() {}
 
-type ReadIntExpr struct{}
+type ReadIntExpr struct {
+	Node
+}
 
 func (n ReadIntExpr) expr() {}
 
+// ReadLongExpr is `readLong()`, a 64-bit value from the long port.
+type ReadLongExpr struct {
+	Node
+}
+
+func (n ReadLongExpr) expr() {}
+
+// TrapCauseExpr is `trapCause()`, the cause of the last trap taken.
+type TrapCauseExpr struct {
+	Node
+}
+
+func (n TrapCauseExpr) expr() {}
+
+// AllocExpr is `alloc(n)`, an array of n elements taken from the heap.
+type AllocExpr struct {
+	Node
+	Size Expr
+}
+
+func (n AllocExpr) expr() {}
+
 type ListEx struct {
-	Size int
+	Node
+	Size     int
+	SizeExpr Expr // size given by a constant expression, folded into Size by sema
 }
 
 func (n ListEx) expr() {}
 
 type ArrayIndexEx struct {
+	Node
 	Target Expr
 	Index  Expr
 }
@@ -58,6 +99,7 @@ func (n ArrayIndexEx) expr() {}
 // --------------------
 
 type BinaryExpr struct {
+	Node
 	Left     Expr
 	Operator lexer.Token
 	Right    Expr
@@ -66,20 +108,34 @@ type BinaryExpr struct {
 func (n BinaryExpr) expr() {}
 
 type AssignmentExpr struct {
+	Node
 	Assigne       Expr
+	Operator      lexer.Token // =, +=, -=, *=, /=, %=, or ++/-- with AssignedValue 1
 	AssignedValue Expr
 }
 
 func (n AssignmentExpr) expr() {}
 
 type PrefixExpr struct {
+	Node
 	Operator lexer.Token
 	Right    Expr
 }
 
 func (n PrefixExpr) expr() {}
 
+// TernaryExpr is `Condition ? Consequent : Alternate`, only the chosen branch is evaluated.
+type TernaryExpr struct {
+	Node
+	Condition  Expr
+	Consequent Expr
+	Alternate  Expr
+}
+
+func (n TernaryExpr) expr() {}
+
 type MemberExpr struct {
+	Node
 	Member   Expr
 	Property string
 }
@@ -87,6 +143,7 @@ type MemberExpr struct {
 func (n MemberExpr) expr() {}
 
 type CallExpr struct {
+	Node
 	Name string
 	Args []Expr
 }
@@ -94,6 +151,7 @@ type CallExpr struct {
 func (n CallExpr) expr() {}
 
 type ComputedExpr struct {
+	Node
 	Member   Expr
 	Property Expr
 }
@@ -101,6 +159,7 @@ type ComputedExpr struct {
 func (n ComputedExpr) expr() {}
 
 type RangeExpr struct {
+	Node
 	Lower Expr
 	Upper Expr
 }
@@ -108,6 +167,7 @@ type RangeExpr struct {
 func (n RangeExpr) expr() {}
 
 type FunctionExpr struct {
+	Node
 	Parameters []Parameter
 	Body       []Stmt
 	ReturnType Type
@@ -116,12 +176,14 @@ type FunctionExpr struct {
 func (n FunctionExpr) expr() {}
 
 type ArrayLiteral struct {
+	Node
 	Contents []Expr
 }
 
 func (n ArrayLiteral) expr() {}
 
 type NewExpr struct {
+	Node
 	Instantiation CallExpr
 }
 
@@ -1,6 +1,9 @@
 package ast
 
+import "github.com/awesoma31/csa-lab4/pkg/translator/lexer"
+
 type BlockStmt struct {
+	Node
 	Body []Stmt
 }
 
@@ -8,25 +11,38 @@ func (b BlockStmt) stmt() {}
 
 // VarDeclarationStmt Var declare
 type VarDeclarationStmt struct {
+	Node
 	Identifier    string
-	AssignedValue Expr
-	// ExplicitType  Type // Используем новый интерфейс Type
+	AssignedValue Expr // nil if omitted, allowed only with an explicit type
+	ExplicitType  Type // type annotation `let x: T`, nil if the type is inferred
 }
 
 func (n VarDeclarationStmt) stmt() {}
 
+// ConstDeclarationStmt is `const N = v;`, v is evaluated at compile time and N takes no memory.
+type ConstDeclarationStmt struct {
+	Node
+	Identifier string
+	Value      Expr
+}
+
+func (n ConstDeclarationStmt) stmt() {}
+
 type ExpressionStmt struct {
+	Node
 	Expression Expr
 }
 
 func (n ExpressionStmt) stmt() {}
 
 type Parameter struct {
+	Pos  lexer.Pos
 	Name string
 	Type Type
 }
 
 type FunctionDeclarationStmt struct {
+	Node
 	Name       string
 	Parameters []Parameter
 	Body       []Stmt
@@ -36,12 +52,14 @@ type FunctionDeclarationStmt struct {
 func (n FunctionDeclarationStmt) stmt() {}
 
 type ReturnStmt struct {
+	Node
 	Expr Expr
 }
 
 func (n ReturnStmt) stmt() {}
 
 type IfStmt struct {
+	Node
 	Condition  Expr
 	Consequent Stmt
 	Alternate  Stmt
@@ -49,24 +67,79 @@ type IfStmt struct {
 
 func (n IfStmt) stmt() {}
 
+// MatchStmt runs the arm whose value equals Subject, or Default (may be nil) if none does.
+type MatchStmt struct {
+	Node
+	Subject Expr
+	Arms    []MatchArm
+	Default Stmt
+}
+
+func (n MatchStmt) stmt() {}
+
+// MatchArm is `v1, v2, ... => { body }`, the values are integer literals.
+type MatchArm struct {
+	Node
+	Values []int32
+	Body   BlockStmt
+}
+
 type WhileStmt struct {
+	Node
 	Condition Expr
 	Body      Stmt
 }
 
 func (n WhileStmt) stmt() {}
 
+// ForStmt is a C-style loop: for init; condition; post { body }
+type ForStmt struct {
+	Node
+	Init      Stmt
+	Condition Expr
+	Post      Expr
+	Body      Stmt
+}
+
+func (n ForStmt) stmt() {}
+
+type BreakStmt struct {
+	Node
+}
+
+func (n BreakStmt) stmt() {}
+
+type ContinueStmt struct {
+	Node
+}
+
+func (n ContinueStmt) stmt() {}
+
 type InterruptionStmt struct {
+	Node
 	IrqNumber int
+	IrqExpr   Expr // number given by a constant expression, folded into IrqNumber by sema
 	Body      Stmt
 }
 
+// TrapStmt is `trap N { }`, the handler of the trap with cause N.
+type TrapStmt struct {
+	Node
+	Cause     int
+	CauseExpr Expr // cause given by a constant expression, folded into Cause by sema
+	Body      Stmt
+}
+
+func (n TrapStmt) stmt() {}
+
 type IntOnStmt struct {
+	Node
 }
 
 func (n IntOnStmt) stmt() {}
 
 type IntOffStmt struct {
+	Node
 }
 
 func (n IntOffStmt) stmt() {}
@@ -74,25 +147,39 @@ func (n IntOffStmt) stmt() {}
 func (n InterruptionStmt) stmt() {}
 
 type PrintStmt struct {
+	Node
 	Argument Expr
 }
 
 func (n PrintStmt) stmt() {}
 
+// FreeStmt is `free(p);`, returns an array taken by alloc to the heap.
+type FreeStmt struct {
+	Node
+	Target Expr
+}
+
+func (n FreeStmt) stmt() {}
+
 type ReadStmt struct {
+	Node
 	Argument Expr
 }
 
 func (n ReadStmt) stmt() {}
 
 type ImportStmt struct {
+	Node
 	Name string
 	From string
 }
 
 func (n ImportStmt) stmt() {}
 
+// ForeachStmt iterates Value over a RangeExpr (lower bound inclusive, upper exclusive)
+// or over the elements of a list or string.
 type ForeachStmt struct {
+	Node
 	Value    string
 	Index    bool
 	Iterable Expr
@@ -102,6 +189,7 @@ type ForeachStmt struct {
 func (n ForeachStmt) stmt() {}
 
 type ClassDeclarationStmt struct {
+	Node
 	Name string
 	Body []Stmt
 }
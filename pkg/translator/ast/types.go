@@ -1,5 +1,7 @@
 package ast
 
+import "fmt"
+
 // TypeKind represents the kind of type (e.g., int, string, bool).
 type TypeKind int
 
@@ -11,6 +13,9 @@ const (
 	TypeFunction                 // 4
 	TypeList                     // 5
 	TypeSymbol                   // 6 // Used for identifiers in type position
+	TypeLong                     // 7 // 64-bit integer, two words
+	TypeByte                     // 8 // element of byte arrays
+	TypeUint                     // 9 // unsigned 32-bit integer
 	// Add more as needed
 )
 
@@ -29,6 +34,12 @@ func (tk TypeKind) String() string {
 		return "list"
 	case TypeSymbol:
 		return "symbol"
+	case TypeLong:
+		return "long"
+	case TypeByte:
+		return "byte"
+	case TypeUint:
+		return "uint"
 	default:
 		return "unknown"
 	}
@@ -66,10 +77,61 @@ func (t ListType) String() string {
 	return "[]" + t.Underlying.String()
 }
 
+// ArrayType is a fixed size array declared with a type annotation, e.g. "[int; 64]".
+// It is stored like a list of Len elements.
+type ArrayType struct {
+	Element Type
+	Len     int
+}
+
+func (t ArrayType) _type() {}
+
+func (t ArrayType) String() string {
+	return fmt.Sprintf("[%s; %d]", t.Element, t.Len)
+}
+
 // Predefined types for convenience
 var (
 	IntType    = SymbolType{Value: "int", Kind: TypeInt}
 	StringType = SymbolType{Value: "string", Kind: TypeString}
 	BoolType   = SymbolType{Value: "bool", Kind: TypeBool}
+	LongType   = SymbolType{Value: "long", Kind: TypeLong}
+	ByteType   = SymbolType{Value: "byte", Kind: TypeByte}
+	UintType   = SymbolType{Value: "uint", Kind: TypeUint}
+	// ByteListType is the type of list(n): a byte buffer whose elements are read as ints.
+	ByteListType = ListType{Underlying: ByteType}
+	// IntListType and LongListType are the types of array literals, elements take 4 and 8 bytes.
+	IntListType  = ListType{Underlying: IntType}
+	LongListType = ListType{Underlying: LongType}
 	// Add more predefined types as needed
 )
+
+// ElementOf returns the element type of a list or an array, nil for other types.
+func ElementOf(t Type) Type {
+	switch t := t.(type) {
+	case ListType:
+		return t.Underlying
+	case ArrayType:
+		return t.Element
+	default:
+		return nil
+	}
+}
+
+// IsUnsized reports whether t is a `[T]` annotation, an array whose size is known at runtime.
+func IsUnsized(t Type) bool {
+	_, ok := t.(ListType)
+	return ok
+}
+
+// KindOf returns the kind of t, TypeUnknown for nil.
+func KindOf(t Type) TypeKind {
+	switch t := t.(type) {
+	case SymbolType:
+		return t.Kind
+	case ListType, ArrayType:
+		return TypeList
+	default:
+		return TypeUnknown
+	}
+}
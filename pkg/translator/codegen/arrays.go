@@ -0,0 +1,156 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
+	"github.com/awesoma31/csa-lab4/pkg/translator/isa"
+)
+
+// Arrays
+//
+// list(n), array literals and arrays declared as [T; N] share one layout in
+// data memory: a header word with the size of the elements in bytes followed
+// by the elements. An array value is the address of its first element.
+//
+//	list(N), [byte; N]       1 byte per element, read zero-extended
+//	[3, 1, 2], [int; N]      4 bytes per element
+//	[1, 2, 3000000000],
+//	[long; N]                8 bytes per element, the low word first
+//
+// The address of arr[i] is arr + i*size, the index is scaled with ADDs.
+//
+// With Options.BoundsCheck the index is checked before scaling against the
+// length taken from the header, the declared size of the array:
+//
+//	SUB RAddr, RM1, #4
+//	MOV RAddr, [RAddr]       the size in bytes
+//	DIV RAddr, RAddr, #size  for int and long elements
+//	BOUND RM2, RAddr         unsigned, a negative index is out of range too
+
+// elementSize returns the size in bytes of an element of the list type t.
+func elementSize(t ast.Type) uint32 {
+	switch ast.KindOf(ast.ElementOf(t)) {
+	case ast.TypeInt, ast.TypeUint, ast.TypeBool:
+		return WordSizeBytes
+	case ast.TypeLong:
+		return WordSizeBytes * 2
+	default:
+		return 1
+	}
+}
+
+// genArrayAddress calculates the address of an array element and stores it in rd.
+// The index goes first, so a call inside it can't clobber the base.
+func (cg *CodeGenerator) genArrayAddress(ix ast.ArrayIndexEx, rd isa.Register) {
+	size := elementSize(ix.Target.StaticType())
+	cg.genEx(ix.Index, isa.RM2)
+	cg.genEx(ix.Target, isa.RM1)
+	if cg.opts.BoundsCheck {
+		cg.genBoundsCheck(size)
+	}
+	for ; size > 1; size >>= 1 {
+		cg.emitInstruction(isa.OpAdd, isa.MathRRR, isa.RM2, isa.RM2, isa.RM2)
+	}
+
+	cg.emitInstruction(isa.OpAdd, isa.MathRRR, rd, isa.RM1, isa.RM2)
+}
+
+// genBoundsCheck traps unless the index in RM2 is below the length of the array in RM1.
+func (cg *CodeGenerator) genBoundsCheck(size uint32) {
+	cg.emitInstruction(isa.OpSub, isa.MathRIR, isa.RAddr, isa.RM1, -1)
+	cg.emitImmediate(WordSizeBytes)
+	cg.emitMov(isa.MvRegIndToReg, isa.RAddr, isa.RAddr, -1)
+	if size > 1 {
+		cg.emitInstruction(isa.OpDiv, isa.MathRIR, isa.RAddr, isa.RAddr, -1)
+		cg.emitImmediate(size)
+	}
+	cg.emitInstruction(isa.OpBound, isa.RegReg, -1, isa.RM2, isa.RAddr)
+}
+
+// genLoadElement loads a byte or word element at the address in addr to rd.
+func (cg *CodeGenerator) genLoadElement(size uint32, rd, addr isa.Register) {
+	if size == 1 {
+		cg.emitMov(isa.MvByteRegIndToReg, rd, addr, -1)
+		return
+	}
+	cg.emitMov(isa.MvRegIndToReg, rd, addr, -1)
+}
+
+// genStoreElement stores rs to a byte or word element at the address in addr.
+func (cg *CodeGenerator) genStoreElement(size uint32, addr, rs isa.Register) {
+	if size == 1 {
+		cg.emitMov(isa.MvLowRegToRegInd, addr, rs, -1)
+		return
+	}
+	cg.emitMov(isa.MvRegToRegInd, addr, rs, -1)
+}
+
+// genLoadLongElement loads the long at the address in RAddr to longLo:longHi.
+func (cg *CodeGenerator) genLoadLongElement() {
+	cg.emitMov(isa.MvRegIndToReg, longLo, isa.RAddr, -1)
+	cg.emitInstruction(isa.OpAdd, isa.MathRIR, isa.RAddr, isa.RAddr, -1)
+	cg.emitImmediate(WordSizeBytes)
+	cg.emitMov(isa.MvRegIndToReg, longHi, isa.RAddr, -1)
+}
+
+// genLongAssignArray generates an assignment to an element of a list of longs,
+// the value is left in longLo:longHi. A compound assignment evaluates the index twice.
+func (cg *CodeGenerator) genLongAssignArray(e ast.AssignmentExpr, target ast.ArrayIndexEx) {
+	if op, compound := longOps[e.Operator.Kind]; compound {
+		cg.genLongBinary(target, op, e.AssignedValue)
+	} else {
+		cg.genLongEx(e.AssignedValue)
+	}
+	saveVal := cg.containsCall(target.Index)
+	if saveVal {
+		cg.emitPushReg(longLo)
+		cg.emitPushReg(longHi)
+	}
+	cg.genArrayAddress(target, isa.RAddr)
+	if saveVal {
+		cg.emitPopToReg(longHi)
+		cg.emitPopToReg(longLo)
+	}
+	cg.emitMov(isa.MvRegToRegInd, isa.RAddr, longLo, -1)
+	cg.emitInstruction(isa.OpAdd, isa.MathRIR, isa.RAddr, isa.RAddr, -1)
+	cg.emitImmediate(WordSizeBytes)
+	cg.emitMov(isa.MvRegToRegInd, isa.RAddr, longHi, -1)
+}
+
+// genArrayLiteral places the elements of an array literal in data memory and returns
+// the address of the first one. Inside a block the elements are stored again every
+// time the literal is evaluated.
+func (cg *CodeGenerator) genArrayLiteral(e ast.ArrayLiteral) uint32 {
+	cg.debugAssembly = append(cg.debugAssembly, "ARRAY LITERAL")
+	size := elementSize(typeOf(e, ast.IntListType))
+	cg.addNumberData(int32(uint32(len(e.Contents)) * size))
+	start := cg.nextDataAddr
+
+	var words []uint32
+	for _, elem := range e.Contents {
+		switch v := elem.(type) {
+		case ast.NumberExpr:
+			if size == WordSizeBytes*2 {
+				cg.addLongData(int64(v.Value))
+				words = append(words, uint32(v.Value), uint32(v.Value>>31))
+				continue
+			}
+			cg.addNumberData(v.Value)
+			words = append(words, uint32(v.Value))
+		case ast.LongNumberExpr:
+			cg.addLongData(v.Value)
+			words = append(words, uint32(v.Value), uint32(v.Value>>32))
+		default:
+			cg.addError(fmt.Sprintf("array element must be a constant, got %T", elem))
+			return start
+		}
+	}
+
+	if cg.inBlock() {
+		for i, w := range words {
+			cg.genLocalInit(start+uint32(i)*WordSizeBytes, w)
+		}
+	}
+	return start
+}
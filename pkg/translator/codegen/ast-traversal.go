@@ -12,11 +12,19 @@ import (
 
 func (cg *CodeGenerator) VisitProgram(p *ast.BlockStmt) {
 	halted := false
-	cg.pushScope()
+	cg.pushScope("global")
+
+	for _, stmt := range p.Body {
+		if fn, ok := stmt.(ast.FunctionDeclarationStmt); ok {
+			cg.declareFunction(fn)
+		}
+	}
 
 	for _, stmt := range p.Body {
 		switch stmt.(type) {
-		case ast.InterruptionStmt:
+		case ast.FunctionDeclarationStmt:
+			// generated after the main program, see genFunctions
+		case ast.InterruptionStmt, ast.TrapStmt:
 			if !halted {
 				cg.emitInstruction(isa.OpHalt, isa.NoOperands, -1, -1, -1)
 				halted = true
@@ -30,35 +38,75 @@ func (cg *CodeGenerator) VisitProgram(p *ast.BlockStmt) {
 	if !halted {
 		cg.emitInstruction(isa.OpHalt, isa.NoOperands, -1, -1, -1)
 	}
+
+	cg.genFunctions()
+	cg.genAllocRoutine()
 }
 
-// generateBlockStmt handles code blocks
+// generateBlockStmt handles code blocks, every block opens its own scope
 func (cg *CodeGenerator) generateBlockStmt(s ast.BlockStmt) {
+	cg.generateScopedBlock(s, "block")
+}
+
+// generateScopedBlock generates block statements inside a new scope labeled name
+func (cg *CodeGenerator) generateScopedBlock(s ast.BlockStmt, name string) {
+	cg.pushScope(name)
 	for _, stmt := range s.Body {
 		cg.generateStmt(stmt)
 	}
+	cg.popScope()
+}
+
+// generateBody generates the body of a control statement in a scope labeled name
+func (cg *CodeGenerator) generateBody(stmt ast.Stmt, name string) {
+	if b, ok := stmt.(ast.BlockStmt); ok {
+		cg.generateScopedBlock(b, name)
+		return
+	}
+	cg.generateStmt(stmt)
 }
 
 func (cg *CodeGenerator) generateStmt(stmt ast.Stmt) {
+	defer cg.enter(stmt)()
 	switch s := stmt.(type) {
 	case ast.VarDeclarationStmt:
 		cg.genVarDeclStmt(s)
+	case ast.ConstDeclarationStmt:
+		// uses are replaced with the value by sema, a constant takes no memory
 	case ast.ExpressionStmt:
 		cg.genEx(s.Expression, isa.RA)
 	case ast.BlockStmt:
 		cg.generateBlockStmt(s)
 	case ast.IfStmt:
 		cg.genIfStmt(s)
+	case ast.MatchStmt:
+		cg.genMatchStmt(s)
 	case ast.PrintStmt:
 		cg.genPrintStmt(s)
 	case ast.WhileStmt:
 		cg.generateWhileStmt(s)
+	case ast.ForStmt:
+		cg.genForStmt(s)
+	case ast.ForeachStmt:
+		cg.genForeachStmt(s)
 	case ast.InterruptionStmt:
 		cg.generateInterStmt(s)
+	case ast.TrapStmt:
+		cg.generateTrapStmt(s)
+	case ast.FreeStmt:
+		cg.genFreeStmt(s)
 	case ast.IntOnStmt:
 		cg.genIntOnStmt()
 	case ast.IntOffStmt:
 		cg.genIntOffStmt()
+	case ast.ReturnStmt:
+		cg.genReturnStmt(s)
+	case ast.BreakStmt:
+		cg.genBreakStmt()
+	case ast.ContinueStmt:
+		cg.genContinueStmt()
+	case ast.FunctionDeclarationStmt:
+		cg.addError(fmt.Sprintf("function '%s' must be declared at the top level", s.Name))
 	default:
 		cg.addError(fmt.Sprintf("Unsupported statement type: %T", s))
 	}
@@ -74,8 +122,8 @@ func (cg *CodeGenerator) genIntOnStmt() {
 
 func (cg *CodeGenerator) generateInterStmt(s ast.InterruptionStmt) {
 	irqN := s.IrqNumber
-	if irqN > maxInterrupts {
-		cg.addError(fmt.Sprintf("Invalid interruption number, must be between 0 and %d", maxInterrupts))
+	if irqN < 0 || irqN >= maxInterrupts {
+		cg.addError(fmt.Sprintf("Invalid interruption number, must be between 0 and %d", maxInterrupts-1))
 		return
 	}
 	cg.debugAssembly = append(cg.debugAssembly, fmt.Sprintf("INTERRUPTION %d STMT", irqN))
@@ -84,7 +132,7 @@ func (cg *CodeGenerator) generateInterStmt(s ast.InterruptionStmt) {
 
 	switch t := s.Body.(type) {
 	case ast.BlockStmt:
-		cg.generateBlockStmt(t)
+		cg.generateScopedBlock(t, fmt.Sprintf("inter %d", irqN))
 	default:
 		cg.addError(fmt.Sprint("interruption body must be block stmt, got: ", litter.Sdump(t)))
 	}
@@ -92,56 +140,57 @@ func (cg *CodeGenerator) generateInterStmt(s ast.InterruptionStmt) {
 	cg.emitInstruction(isa.OpIRet, isa.NoOperands, isa.Register(irqN), -1, -1)
 }
 
-func (cg *CodeGenerator) generateWhileStmt(s ast.WhileStmt) {
-	conditionAddr := cg.nextInstructionAddr
-	cg.debugAssembly = append(cg.debugAssembly, "WHILE STATEMENT CONDITION:")
-
-	var operator lexer.Token
-	switch cond := s.Condition.(type) {
-	case ast.BinaryExpr:
-		operator = cond.Operator
-	default:
-		cg.addError(fmt.Sprintf("unsupported condition type in while loop: %T", cond))
+// generateTrapStmt generates the handler of a trap and sets its vector,
+// the handler returns like an interruption one.
+func (cg *CodeGenerator) generateTrapStmt(s ast.TrapStmt) {
+	if s.Cause < 0 || s.Cause >= int(isa.TrapCount) {
+		cg.addError(fmt.Sprintf("Invalid trap number, must be between 0 and %d", isa.TrapCount-1))
 		return
 	}
+	cg.debugAssembly = append(cg.debugAssembly, fmt.Sprintf("TRAP %d STMT", s.Cause))
+
+	cg.instructionMemory[trapVectorBaseAddr+s.Cause] = cg.nextInstructionAddr
 
-	cg.genEx(s.Condition, -1)
-
-	var jmpToEndOpc uint32
-	switch operator.Kind {
-	case lexer.EQUALS:
-		jmpToEndOpc = isa.OpJne // Jump if Not Equal
-	case lexer.NotEquals:
-		jmpToEndOpc = isa.OpJe // Jump if Equal
-	case lexer.GREATER:
-		jmpToEndOpc = isa.OpJle // Jump if Less or Equal
-	case lexer.LESS:
-		jmpToEndOpc = isa.OpJge // Jump if Greater or Equal
-	case lexer.GreaterEquals:
-		jmpToEndOpc = isa.OpJl // Jump if Less
-	case lexer.LessEquals:
-		jmpToEndOpc = isa.OpJg // Jump if Greater
+	switch t := s.Body.(type) {
+	case ast.BlockStmt:
+		cg.generateScopedBlock(t, fmt.Sprintf("trap %d", s.Cause))
 	default:
-		cg.addError(fmt.Sprintf("unsupported operator in while condition: %s", operator.Value))
-		jmpToEndOpc = isa.OpJmp
+		cg.addError(fmt.Sprint("trap body must be block stmt, got: ", litter.Sdump(t)))
 	}
 
-	cg.emitInstruction(jmpToEndOpc, isa.JAbsAddr, -1, -1, -1)
-	addrToPatchEnd := cg.ReserveWord()
+	cg.emitInstruction(isa.OpIRet, isa.NoOperands, -1, -1, -1)
+}
+
+func (cg *CodeGenerator) generateWhileStmt(s ast.WhileStmt) {
+	conditionAddr := cg.nextInstructionAddr
+	cg.debugAssembly = append(cg.debugAssembly, "WHILE STATEMENT CONDITION:")
+
+	falsePatches := cg.genJumpIfFalse(s.Condition)
 
 	cg.debugAssembly = append(cg.debugAssembly, "WHILE STMT BODY:")
-	cg.generateStmt(s.Body)
+	loop := cg.pushLoop()
+	cg.generateBody(s.Body, "while")
+	cg.popLoop()
 
 	cg.emitInstruction(isa.OpJmp, isa.JAbsAddr, -1, -1, -1)
 	cg.emitImmediate(conditionAddr)
 
-	afterLoopAddr := cg.nextInstructionAddr
-	cg.PatchWord(addrToPatchEnd, afterLoopAddr)
+	cg.patchJumps(falsePatches, cg.nextInstructionAddr)
+	cg.patchJumps(loop.breakPatches, cg.nextInstructionAddr)
+	cg.patchJumps(loop.continuePatches, conditionAddr)
 	cg.debugAssembly = append(cg.debugAssembly, " # END OF WHILE STMT")
 }
 
 func (cg *CodeGenerator) genPrintStmt(s ast.PrintStmt) {
 	cg.debugAssembly = append(cg.debugAssembly, "PRINT STMT")
+	if isLong(s.Argument) || isUnsigned(s.Argument) {
+		cg.genPrintLong(s.Argument)
+		return
+	}
+	if cg.printsBool(s.Argument) {
+		cg.genPrintBool(s.Argument)
+		return
+	}
 	switch arg := s.Argument.(type) {
 	case ast.StringExpr:
 		cg.genStringExPl1(arg, isa.ROutAddr)
@@ -185,56 +234,117 @@ func (cg *CodeGenerator) genPrintStmt(s ast.PrintStmt) {
 			return
 
 		} else {
-			cg.genEx(s.Argument, isa.ROutAddr)
-			cg.emitMov(isa.MvRegIndToReg, isa.RC, isa.ROutAddr, -1) // mov rc <- mem[routaddr]
-			cg.emitInstruction(isa.OpAnd, isa.ImmReg, isa.RC, isa.RC, -1)
-			cg.emitImmediate(0xFF)
-			// add 1 to routaddr bcs generateExpr will store ptr to str len initially
-			cg.emitInstruction(isa.OpAdd, isa.MathRIR, isa.ROutAddr, isa.ROutAddr, -1)
-			cg.emitImmediate(1)
-			// routaddr = addr of str 1 char
-			// rcounter addr len
-
-			cmpAddr := cg.nextInstructionAddr
-			cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, isa.RC, isa.ZERO)
-			cg.emitInstruction(isa.OpJe, isa.JAbsAddr, -1, -1, -1)
-			jToEndAddr := cg.ReserveWord()
-			cg.emitMov(isa.MvByteRegIndToReg, isa.ROutData, isa.ROutAddr, -1)
-
-			cg.emitInstruction(isa.OpOut, isa.ByteM, isa.PortCh, -1, -1)
-			cg.emitInstruction(isa.OpSub, isa.MathRIR, isa.RC, isa.RC, -1)
-			cg.emitImmediate(1)
-			cg.emitInstruction(isa.OpAdd, isa.MathRIR, isa.ROutAddr, isa.ROutAddr, -1)
-			cg.emitImmediate(1)
-			cg.emitInstruction(isa.OpJmp, isa.JAbsAddr, -1, -1, -1)
-			cg.emitImmediate(cmpAddr)
-
-			afterEndAddr := cg.nextInstructionAddr
-			cg.PatchWord(jToEndAddr, afterEndAddr)
+			cg.genPrintStrEx(s.Argument)
 		}
 
-	case ast.NumberExpr:
+	case ast.NumberExpr, ast.BinaryExpr, ast.CallExpr, ast.PrefixExpr:
 		cg.genEx(arg, isa.ROutData)
-		cg.emitInstruction(isa.OpOut, isa.NoOperands, isa.PortD, -1, -1)
+		cg.emitInstruction(isa.OpOut, isa.DigitM, isa.PortD, -1, -1)
 
 	default:
+		// any other expression checked to be a number or a string, e.g. arr[i], readInt() or c ? "a" : "b"
+		switch ast.KindOf(arg.StaticType()) {
+		case ast.TypeInt, ast.TypeBool:
+			cg.genEx(arg, isa.ROutData)
+			cg.emitInstruction(isa.OpOut, isa.DigitM, isa.PortD, -1, -1)
+			return
+		case ast.TypeString:
+			cg.genPrintStrEx(arg)
+			return
+		}
 		cg.addError(fmt.Sprintf("Unsupported argument type for print: %T", arg))
 	}
 }
 
+// genPrintStrEx prints the string expr points to, char by char to the char port.
+func (cg *CodeGenerator) genPrintStrEx(expr ast.Expr) {
+	cg.genEx(expr, isa.ROutAddr)
+	cg.genPrintStrAt()
+}
+
+// printsBool reports whether print shows expr as `true` or `false`: a bool literal
+// or a variable declared from one. Other bool values, e.g. comparisons, print as 0 or 1.
+func (cg *CodeGenerator) printsBool(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case ast.BoolExpr:
+		return true
+	case ast.SymbolExpr:
+		symbol, found := cg.lookupSymbol(e.Value)
+		return found && symbol.IsBool
+	}
+	return false
+}
+
+// genPrintBool prints `true` or `false` to the char port.
+func (cg *CodeGenerator) genPrintBool(expr ast.Expr) {
+	if cg.boolNames[0] == 0 {
+		cg.boolNames = [2]uint32{cg.addString("false"), cg.addString("true")}
+	}
+	falsePatches := cg.genJumpIfFalse(expr)
+	cg.emitMov(isa.MvImmReg, isa.ROutAddr, isa.Register(cg.boolNames[1]), -1)
+	printPatch := cg.emitJump(isa.OpJmp)
+	cg.patchJumps(falsePatches, cg.nextInstructionAddr)
+	cg.emitMov(isa.MvImmReg, isa.ROutAddr, isa.Register(cg.boolNames[0]), -1)
+	cg.PatchWord(printPatch, cg.nextInstructionAddr)
+	cg.genPrintStrAt()
+}
+
+// genPrintStrAt prints the string whose address is in ROutAddr.
+func (cg *CodeGenerator) genPrintStrAt() {
+	cg.emitMov(isa.MvRegIndToReg, isa.RC, isa.ROutAddr, -1) // mov rc <- mem[routaddr]
+	cg.emitInstruction(isa.OpAnd, isa.ImmReg, isa.RC, isa.RC, -1)
+	cg.emitImmediate(0xFF)
+	// add 1 to routaddr bcs generateExpr will store ptr to str len initially
+	cg.emitInstruction(isa.OpAdd, isa.MathRIR, isa.ROutAddr, isa.ROutAddr, -1)
+	cg.emitImmediate(1)
+	// routaddr = addr of str 1 char
+	// rcounter addr len
+
+	cmpAddr := cg.nextInstructionAddr
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, isa.RC, isa.ZERO)
+	cg.emitInstruction(isa.OpJe, isa.JAbsAddr, -1, -1, -1)
+	jToEndAddr := cg.ReserveWord()
+	cg.emitMov(isa.MvByteRegIndToReg, isa.ROutData, isa.ROutAddr, -1)
+
+	cg.emitInstruction(isa.OpOut, isa.ByteM, isa.PortCh, -1, -1)
+	cg.emitInstruction(isa.OpSub, isa.MathRIR, isa.RC, isa.RC, -1)
+	cg.emitImmediate(1)
+	cg.emitInstruction(isa.OpAdd, isa.MathRIR, isa.ROutAddr, isa.ROutAddr, -1)
+	cg.emitImmediate(1)
+	cg.emitInstruction(isa.OpJmp, isa.JAbsAddr, -1, -1, -1)
+	cg.emitImmediate(cmpAddr)
+
+	afterEndAddr := cg.nextInstructionAddr
+	cg.PatchWord(jToEndAddr, afterEndAddr)
+}
+
 // genEx generates code for a given expression, leaving its result in specified register.
 func (cg *CodeGenerator) genEx(expr ast.Expr, rd isa.Register) {
+	defer cg.enter(expr)()
 	switch e := expr.(type) {
 	case ast.NumberExpr:
 		cg.emitMov(isa.MvImmReg, rd, isa.Register(e.Value), -1)
+	case ast.BoolExpr:
+		cg.emitMov(isa.MvImmReg, rd, isa.Register(boolWord(e.Value)), -1)
 	case ast.LongNumberExpr:
 		cg.addError("generating long expr is not supported")
 
 	case ast.ArrayIndexEx:
 		cg.genArrayAddress(e, isa.RAddr)
-		cg.emitMov(isa.MvByteRegIndToReg, rd, isa.RAddr, -1)
+		cg.genLoadElement(elementSize(e.Target.StaticType()), rd, isa.RAddr)
+
+	case ast.ArrayLiteral:
+		cg.emitMov(isa.MvImmReg, rd, isa.Register(cg.genArrayLiteral(e)), -1)
 
 	case ast.BinaryExpr:
+		if rd != -1 && isBoolOperator(e.Operator.Kind) {
+			cg.genBoolValue(e, rd)
+			return
+		}
+		if isUnsignedDiv(e) {
+			cg.genUnsignedDiv(e, rd)
+			return
+		}
 		cg.genEx(e.Left, isa.RM1)
 		cg.emitPushReg(isa.RM1)
 
@@ -243,6 +353,7 @@ func (cg *CodeGenerator) genEx(expr ast.Expr, rd isa.Register) {
 		cg.emitPopToReg(isa.RM1)
 
 		var opcode uint32
+		mode := isa.MathRRR
 		switch e.Operator.Kind {
 		case lexer.PLUS:
 			opcode = isa.OpAdd
@@ -252,19 +363,31 @@ func (cg *CodeGenerator) genEx(expr ast.Expr, rd isa.Register) {
 			opcode = isa.OpMul
 		case lexer.SLASH:
 			opcode = isa.OpDiv
+		case lexer.PERCENT:
+			opcode = isa.OpRem
+		case lexer.AMPERSAND:
+			opcode, mode = isa.OpAnd, isa.RegReg
+		case lexer.PIPE:
+			opcode, mode = isa.OpOr, isa.RegReg
+		case lexer.CARET:
+			opcode, mode = isa.OpXor, isa.RegReg
+		case lexer.ShiftLeft:
+			opcode = isa.OpShl
+		case lexer.ShiftRight:
+			opcode = isa.OpSar
+			if isUnsigned(e.Left) {
+				opcode = isa.OpShr
+			}
+		case lexer.ShiftRightUnsigned:
+			opcode = isa.OpShr
 		case lexer.EQUALS, lexer.NotEquals, lexer.GREATER, lexer.GreaterEquals, lexer.LESS, lexer.LessEquals:
-			opcode = isa.OpCmp
+			opcode, mode = isa.OpCmp, isa.RegReg
 			rd = -1
 		default:
 			cg.addError(fmt.Sprintf("Unsupported binary operator: %s", e.Operator.Value))
 			return
 		}
-		if opcode != isa.OpCmp {
-			cg.emitInstruction(opcode, isa.MathRRR, rd, isa.RM1, isa.RM2)
-		} else {
-			cg.emitInstruction(opcode, isa.RegReg, rd, isa.RM1, isa.RM2)
-
-		}
+		cg.emitInstruction(opcode, mode, rd, isa.RM1, isa.RM2)
 
 	case ast.SymbolExpr:
 		symbol, found := cg.lookupSymbol(e.Value)
@@ -279,15 +402,24 @@ func (cg *CodeGenerator) genEx(expr ast.Expr, rd isa.Register) {
 		cg.emitInstruction(isa.OpMov, isa.MvMemReg, rd, -1, -1)
 		cg.emitImmediate(symbol.AbsAddress)
 	case ast.StringExpr:
-		cg.genStringExPl1(e, rd)
+		cg.genStringEx(e, rd)
 	case ast.CallExpr:
 		switch e.Name {
 		case lexer.TokenKindString(lexer.ADDSTR):
 			cg.genAddStrc(e, rd)
 		default:
-			cg.addError(fmt.Sprintf("unknown func name %s", e.Name))
+			cg.genCallEx(e, rd)
 		}
 	case ast.PrefixExpr:
+		switch e.Operator.Kind {
+		case lexer.NOT:
+			cg.genBoolValue(e, rd)
+			return
+		case lexer.TILDE:
+			cg.genEx(e.Right, rd)
+			cg.emitInstruction(isa.OpNot, isa.RegReg, rd, rd, -1)
+			return
+		}
 		newExpr := ast.NumberExpr{}
 		switch a := e.Right.(type) {
 		case ast.NumberExpr:
@@ -302,34 +434,61 @@ func (cg *CodeGenerator) genEx(expr ast.Expr, rd isa.Register) {
 			}
 			cg.genEx(newExpr, rd)
 		default:
-			panic("unimpl prefix functionality, only unary with numbers work for now")
+			cg.genEx(e.Right, rd)
+			if e.Operator.Kind == lexer.MINUS && rd != -1 {
+				cg.emitInstruction(isa.OpSub, isa.MathRRR, rd, isa.ZERO, rd)
+			}
 		}
 	case ast.AssignmentExpr:
 		cg.genAssignEx(e, rd)
+	case ast.TernaryExpr:
+		cg.genTernaryEx(e, rd)
 	case ast.ReadChExpr:
 		cg.genReadChEx(rd)
 	case ast.ReadIntExpr:
 		cg.genReadIntEx(rd)
+	case ast.TrapCauseExpr:
+		cg.emitMov(isa.MvCauseReg, rd, -1, -1)
+	case ast.AllocExpr:
+		cg.genAllocEx(e, rd)
 	default:
 		cg.addError(fmt.Sprintf("Unsupported expression type: %T", e))
 	}
 }
 
+// genTernaryEx generates `cond ? a : b`, leaving the value of the chosen branch in rd.
+//
+//	cond, jump if false -> F
+//	a -> rd
+//	JMP end
+//	F: b -> rd
+//	end:
+func (cg *CodeGenerator) genTernaryEx(e ast.TernaryExpr, rd isa.Register) {
+	falsePatches := cg.genJumpIfFalse(e.Condition)
+	cg.genEx(e.Consequent, rd)
+	endPatch := cg.emitJump(isa.OpJmp)
+	cg.patchJumps(falsePatches, cg.nextInstructionAddr)
+	cg.genEx(e.Alternate, rd)
+	cg.PatchWord(endPatch, cg.nextInstructionAddr)
+}
+
+// FindSymbol looks up a variable, reporting an error and returning nil if it is undeclared.
 func (cg *CodeGenerator) FindSymbol(arg ast.SymbolExpr) *SymbolEntry {
-	if s1, found := cg.currentScope().symbols[arg.Value]; found {
+	if s1, found := cg.lookupSymbol(arg.Value); found {
 		return &s1
 	}
-	panic("undeclared variable")
+	cg.addError(fmt.Sprintf("Undeclared variable '%s'", arg.Value))
+	return nil
 }
+
+// FindSymbolFromEx is FindSymbol for an expression that must be a variable.
 func (cg *CodeGenerator) FindSymbolFromEx(arg ast.Expr) *SymbolEntry {
 	switch e := arg.(type) {
 	case ast.SymbolExpr:
-		if s1, found := cg.currentScope().symbols[e.Value]; found {
-			return &s1
-		}
-		panic("undeclared variable")
+		return cg.FindSymbol(e)
 	default:
-		panic("unknown")
+		cg.addError(fmt.Sprintf("expected a variable, got %T", arg))
+		return nil
 	}
 }
 
@@ -361,8 +520,15 @@ func (cg *CodeGenerator) genReadIntEx(rd isa.Register) {
 }
 
 func (cg *CodeGenerator) genVarDeclStmt(s ast.VarDeclarationStmt) {
-	if _, found := cg.currentScope().symbols[s.Identifier]; found {
-		cg.addError(fmt.Sprintf("Variable '%s' already declared ", s.Identifier))
+	if cg.declaredInCurrentScope(s.Identifier) {
+		return
+	}
+	if s.ExplicitType != nil {
+		cg.genAnnotatedVarDecl(s)
+		return
+	}
+	if _, literal := s.AssignedValue.(ast.LongNumberExpr); !literal && isLong(s.AssignedValue) {
+		cg.genLongVarDecl(s)
 		return
 	}
 
@@ -373,7 +539,7 @@ func (cg *CodeGenerator) genVarDeclStmt(s ast.VarDeclarationStmt) {
 	if s.AssignedValue != nil {
 		switch assignedVal := s.AssignedValue.(type) {
 		case ast.LongNumberExpr:
-			symbolEntry.Type = ast.IntType
+			symbolEntry.Type = ast.LongType
 			symbolEntry.SizeInBytes = WordSizeBytes * 2
 			symbolEntry.NumberValue = int32(assignedVal.Value)
 			symbolEntry.LongValue = assignedVal.Value
@@ -382,6 +548,10 @@ func (cg *CodeGenerator) genVarDeclStmt(s ast.VarDeclarationStmt) {
 			symbolEntry.MemoryArea = "data"
 			symbolEntry.AbsAddress = cg.addLongData(symbolEntry.LongValue)
 			cg.addSymbolToScope(symbolEntry)
+			if cg.inBlock() {
+				cg.genLocalInit(symbolEntry.AbsAddress, uint32(assignedVal.Value))
+				cg.genLocalInit(symbolEntry.AbsAddress+WordSizeBytes, uint32(assignedVal.Value>>32))
+			}
 		case ast.NumberExpr:
 			symbolEntry.Type = ast.IntType
 			symbolEntry.SizeInBytes = WordSizeBytes
@@ -390,22 +560,28 @@ func (cg *CodeGenerator) genVarDeclStmt(s ast.VarDeclarationStmt) {
 			symbolEntry.MemoryArea = "data"
 			symbolEntry.AbsAddress = cg.addNumberData(assignedVal.Value)
 			cg.addSymbolToScope(symbolEntry)
+			if cg.inBlock() {
+				cg.genLocalInit(symbolEntry.AbsAddress, uint32(assignedVal.Value))
+			}
 
 		case ast.StringExpr:
 			strAddr := cg.addString(assignedVal.Value)
 			ptrAddr := cg.addNumberData(int32(strAddr))
-			symbolEntry.Type = ast.IntType
+			symbolEntry.Type = ast.StringType
 			symbolEntry.SizeInBytes = WordSizeBytes
 			symbolEntry.NumberValue = int32(strAddr)
 			symbolEntry.AbsAddress = ptrAddr
 			symbolEntry.IsStr = true
 			symbolEntry.MemoryArea = "data"
 			cg.addSymbolToScope(symbolEntry)
+			if cg.inBlock() {
+				cg.genLocalInit(ptrAddr, strAddr)
+			}
 		case ast.ReadChExpr:
 			strAddr := cg.addString("")
 			ptrAddr := cg.addNumberData(int32(strAddr))
 
-			symbolEntry.Type = ast.IntType
+			symbolEntry.Type = ast.StringType
 			symbolEntry.SizeInBytes = WordSizeBytes
 			symbolEntry.NumberValue = int32(strAddr)
 			symbolEntry.AbsAddress = ptrAddr
@@ -435,7 +611,7 @@ func (cg *CodeGenerator) genVarDeclStmt(s ast.VarDeclarationStmt) {
 			return
 
 		case ast.BinaryExpr:
-			symbolEntry.Type = ast.IntType
+			symbolEntry.Type = typeOf(assignedVal, ast.IntType)
 			symbolEntry.SizeInBytes = WordSizeBytes
 
 			symbolEntry.MemoryArea = "data"
@@ -450,7 +626,7 @@ func (cg *CodeGenerator) genVarDeclStmt(s ast.VarDeclarationStmt) {
 			cg.genAssignEx(assign, isa.RA)
 			return
 		case ast.PrefixExpr:
-			symbolEntry.Type = ast.IntType
+			symbolEntry.Type = typeOf(assignedVal, ast.IntType)
 			symbolEntry.SizeInBytes = WordSizeBytes
 
 			symbolEntry.MemoryArea = "data"
@@ -464,6 +640,8 @@ func (cg *CodeGenerator) genVarDeclStmt(s ast.VarDeclarationStmt) {
 			cg.genAssignEx(assign, isa.RA)
 			return
 		case ast.ListEx:
+			// the header word before the buffer keeps its size in bytes
+			cg.addNumberData(int32(assignedVal.Size))
 			listPtr := cg.nextDataAddr
 			cg.dataMemory = append(cg.dataMemory, make([]byte, assignedVal.Size)...)
 			cg.nextDataAddr += uint32(assignedVal.Size)
@@ -471,7 +649,7 @@ func (cg *CodeGenerator) genVarDeclStmt(s ast.VarDeclarationStmt) {
 
 			ptrAddr := cg.addNumberData(int32(listPtr))
 
-			symbolEntry.Type = ast.IntType
+			symbolEntry.Type = typeOf(assignedVal, ast.ByteListType)
 			symbolEntry.SizeInBytes = WordSizeBytes
 			symbolEntry.AbsAddress = ptrAddr
 			symbolEntry.MemoryArea = "data"
@@ -486,8 +664,11 @@ func (cg *CodeGenerator) genVarDeclStmt(s ast.VarDeclarationStmt) {
 
 			cg.genEx(assignedVal, isa.RT2)
 
-			cg.emitInstruction(isa.OpMov, isa.MvRegLowToMem, -1, isa.RT2, -1)
-			cg.emitImmediate(symbolEntry.AbsAddress)
+			if elementSize(assignedVal.Target.StaticType()) == 1 {
+				cg.emitMov(isa.MvRegLowToMem, isa.Register(symbolEntry.AbsAddress), isa.RT2, -1)
+			} else {
+				cg.emitMov(isa.MvRegMem, isa.Register(symbolEntry.AbsAddress), isa.RT2, -1)
+			}
 		case ast.CallExpr:
 			switch assignedVal.Name {
 			case lexer.TokenKindString(lexer.ADDSTR):
@@ -498,7 +679,7 @@ func (cg *CodeGenerator) genVarDeclStmt(s ast.VarDeclarationStmt) {
 				ptrAddr := cg.addNumberData(0)
 				sym := SymbolEntry{
 					Name:        s.Identifier,
-					Type:        ast.IntType,
+					Type:        ast.StringType,
 					SizeInBytes: WordSizeBytes,
 					AbsAddress:  ptrAddr,
 					IsStr:       true,
@@ -510,42 +691,25 @@ func (cg *CodeGenerator) genVarDeclStmt(s ast.VarDeclarationStmt) {
 
 				cg.emitInstruction(isa.OpMov, isa.MvRegMem, -1, isa.RA, -1)
 				cg.emitImmediate(ptrAddr)
-			case lexer.TokenKindString(lexer.ADDL):
-				if len(assignedVal.Args) != 2 {
-					cg.addError(fmt.Sprintf("%s( , ) must have 2 arguments, got %d", lexer.TokenKindString(lexer.ADDL), len(assignedVal.Args)))
-					return
-				}
-				if _, ok := assignedVal.Args[0].(ast.SymbolExpr); !ok {
-					panic("argument must be variable")
-				}
-				if _, ok := assignedVal.Args[1].(ast.SymbolExpr); !ok {
-					panic("")
-				}
-				arg1e := assignedVal.Args[0].(ast.SymbolExpr)
-				arg2e := assignedVal.Args[1].(ast.SymbolExpr)
-				s1, found1 := cg.lookupSymbol(arg1e.Value)
-				s2, found2 := cg.lookupSymbol(arg2e.Value)
-				if !found1 || !found2 {
-					cg.addError(fmt.Sprintf("Undeclared variable in print expr: %s or %s; %v", arg1e.Value, arg2e.Value, litter.Sdump(cg.scopeStack)))
-					return
-				}
-				ptrAddr := cg.addLongData(s1.LongValue + s2.LongValue)
-				sym := SymbolEntry{
-					Name:        s.Identifier,
-					Type:        ast.IntType,
-					SizeInBytes: WordSizeBytes * 2,
-					AbsAddress:  ptrAddr,
-					IsLong:      true,
-					MemoryArea:  "data",
-				}
-				cg.addSymbolToScope(sym)
-
 			default:
-				cg.addError(fmt.Sprintf("unknown function name %v", assignedVal.Name))
+				symbolEntry.Type = ast.IntType
+				symbolEntry.SizeInBytes = WordSizeBytes
+				symbolEntry.MemoryArea = "data"
+				symbolEntry.AbsAddress = cg.addNumberData(0)
+				cg.addSymbolToScope(symbolEntry)
+
+				assign := ast.AssignmentExpr{
+					Assigne:       ast.SymbolExpr{Value: s.Identifier},
+					AssignedValue: s.AssignedValue,
+				}
+				cg.genAssignEx(assign, isa.RA)
 			}
 
+		case ast.BoolExpr:
+			symbolEntry.IsBool = true
+			cg.genTypedVarDecl(s, symbolEntry)
 		default:
-			cg.addError(fmt.Sprintf("unknown case of generating var declaration - %T", assignedVal))
+			cg.genTypedVarDecl(s, symbolEntry)
 		}
 	} else {
 		cg.addError(fmt.Sprintf("All variables should be initialized: %s - is undefined", s.Identifier))
@@ -554,6 +718,116 @@ func (cg *CodeGenerator) genVarDeclStmt(s ast.VarDeclarationStmt) {
 
 }
 
+// genAnnotatedVarDecl declares `let x: T [= v];`, the annotation decides the storage:
+// a long takes two words even for a small value, a string without a value gets an empty
+// buffer of the maximum length, an array is laid out like list(n), a [T] keeps the array
+// it is given. A missing value is zero.
+func (cg *CodeGenerator) genAnnotatedVarDecl(s ast.VarDeclarationStmt) {
+	typ := s.ExplicitType
+	// `let b: bool;` starts as false and prints like a variable declared from a literal
+	isBool := ast.KindOf(typ) == ast.TypeBool && s.AssignedValue == nil
+	switch ast.KindOf(typ) {
+	case ast.TypeList:
+		if ast.IsUnsized(typ) {
+			// [T] = alloc(n) or another array, only the pointer is stored
+			break
+		}
+		arr, ok := typ.(ast.ArrayType)
+		if !ok {
+			cg.addError(fmt.Sprintf("unsupported array type %s", typ))
+			return
+		}
+		list := ast.ListEx{Size: arr.Len * int(elementSize(arr))}
+		list.Type = arr
+		s.AssignedValue = list
+	case ast.TypeString:
+		if s.AssignedValue == nil {
+			strAddr := cg.addStringBuffer(maxStringLength)
+			ptrAddr := cg.addNumberData(int32(strAddr))
+			cg.addSymbolToScope(SymbolEntry{
+				Name:        s.Identifier,
+				Type:        typ,
+				MemoryArea:  "data",
+				AbsAddress:  ptrAddr,
+				SizeInBytes: WordSizeBytes,
+				NumberValue: int32(strAddr),
+				IsStr:       true,
+			})
+			if cg.inBlock() {
+				cg.genLocalInit(ptrAddr, strAddr)
+			}
+			return
+		}
+	case ast.TypeLong:
+		if v, ok := longLiteral(s.AssignedValue); ok {
+			s.AssignedValue = ast.LongNumberExpr{Value: v}
+		} else {
+			cg.genLongVarDecl(s)
+			return
+		}
+	default:
+		if s.AssignedValue == nil {
+			s.AssignedValue = ast.NumberExpr{Value: 0}
+		}
+	}
+
+	s.ExplicitType = nil
+	cg.genVarDeclStmt(s)
+	if sym, found := cg.currentScope().symbols[s.Identifier]; found {
+		sym.Type = typ
+		sym.IsBool = sym.IsBool || isBool
+		cg.currentScope().symbols[s.Identifier] = sym
+	}
+}
+
+// longLiteral returns the value of a long variable initializer known at compile time,
+// a missing value is zero.
+func longLiteral(expr ast.Expr) (int64, bool) {
+	switch e := expr.(type) {
+	case nil:
+		return 0, true
+	case ast.NumberExpr:
+		return int64(e.Value), true
+	case ast.LongNumberExpr:
+		return e.Value, true
+	case ast.PrefixExpr:
+		if n, ok := e.Right.(ast.NumberExpr); ok && e.Operator.Kind == lexer.MINUS {
+			return -int64(n.Value), true
+		}
+	}
+	return 0, false
+}
+
+// genTypedVarDecl declares a variable initialized by an arbitrary expression, e.g. another variable.
+// The checked type of the value decides the layout: ints, bools, strings and lists take a word
+// (strings and lists keep a pointer, so the value is shared).
+func (cg *CodeGenerator) genTypedVarDecl(s ast.VarDeclarationStmt, symbolEntry SymbolEntry) {
+	typ := s.AssignedValue.StaticType()
+	switch ast.KindOf(typ) {
+	case ast.TypeInt, ast.TypeUint, ast.TypeBool, ast.TypeString, ast.TypeList:
+	default:
+		cg.addError(fmt.Sprintf("unknown case of generating var declaration - %T", s.AssignedValue))
+		return
+	}
+	symbolEntry.Type = typ
+	symbolEntry.SizeInBytes = WordSizeBytes
+	symbolEntry.MemoryArea = "data"
+	symbolEntry.AbsAddress = cg.addNumberData(0)
+	symbolEntry.IsStr = ast.KindOf(typ) == ast.TypeString
+	cg.addSymbolToScope(symbolEntry)
+
+	cg.genEx(s.AssignedValue, isa.RA)
+	cg.emitMov(isa.MvRegMem, isa.Register(symbolEntry.AbsAddress), isa.RA, -1)
+}
+
+// typeOf returns the checked type of expr, or def if the program was not checked.
+func typeOf(expr ast.Expr, def ast.Type) ast.Type {
+	if t := expr.StaticType(); t != nil {
+		return t
+	}
+	return def
+}
+
 func (cg *CodeGenerator) genAddStrc(call ast.CallExpr, rd isa.Register) {
 	var s1Len byte
 	var s2Len byte
@@ -600,11 +874,40 @@ func (cg *CodeGenerator) genAddStrc(call ast.CallExpr, rd isa.Register) {
 	cg.genStringEx(ast.StringExpr{Value: newStr}, rd)
 }
 
+// compoundOps maps compound assignment operators to the ALU operation applied to the target.
+var compoundOps = map[lexer.TokenKind]uint32{
+	lexer.PlusEquals:    isa.OpAdd,
+	lexer.MinusEquals:   isa.OpSub,
+	lexer.StarEquals:    isa.OpMul,
+	lexer.SlashEquals:   isa.OpDiv,
+	lexer.PercentEquals: isa.OpRem,
+	lexer.PlusPlus:      isa.OpAdd,
+	lexer.MinusMinus:    isa.OpSub,
+}
+
 func (cg *CodeGenerator) genAssignEx(e ast.AssignmentExpr, rd isa.Register) {
+	if isLong(e.Assigne) {
+		cg.genLongAssign(e)
+		return
+	}
+	if op, ok := longOps[e.Operator.Kind]; ok && isUnsigned(e.Assigne) && (op.Kind == lexer.SLASH || op.Kind == lexer.PERCENT) {
+		// DIV and REM are signed, `x /= v` is generated as `x = x / v`
+		value := ast.BinaryExpr{Left: e.Assigne, Operator: op, Right: e.AssignedValue}
+		value.Type = ast.UintType
+		e.Operator = lexer.Token{Kind: lexer.ASSIGNMENT, Value: "="}
+		e.AssignedValue = value
+	}
+	if opcode, ok := compoundOps[e.Operator.Kind]; ok {
+		cg.genCompoundAssign(e, opcode, rd)
+		return
+	}
 	// Evaluate the right-hand side expression, result is left in rd
-	switch r := e.AssignedValue.(type) {
+	switch e.AssignedValue.(type) {
 	case ast.ReadChExpr:
 		trg := cg.FindSymbolFromEx(e.Assigne)
+		if trg == nil {
+			return
+		}
 		trg.IsRead = true
 		trg.IsStr = true
 		trg.IsLong = false
@@ -614,26 +917,11 @@ func (cg *CodeGenerator) genAssignEx(e ast.AssignmentExpr, rd isa.Register) {
 		cg.emitImmediate(uint32(trg.NumberValue))
 		return
 
-	case ast.CallExpr:
-		switch r.Name {
-		case lexer.TokenKindString(lexer.ADDL):
-			targetS := cg.FindSymbolFromEx(e.Assigne)
-			cg.genAddLongAssign(r.Args, e.Assigne.(ast.SymbolExpr))
-
-			cg.emitInstruction(isa.OpMov, isa.MvRegIndToReg, isa.RA, rd, -1)
-			cg.emitMov(isa.MvRegMem, isa.Register(targetS.AbsAddress), isa.RA, -1)
-
-			cg.emitInstruction(isa.OpAdd, isa.MathRIR, rd, rd, -1)
-			cg.emitImmediate(4)
-			cg.emitInstruction(isa.OpMov, isa.MvRegIndToReg, isa.RA, rd, -1)
-			cg.emitMov(isa.MvRegMem, isa.Register(targetS.AbsAddress+4), isa.RA, -1)
-			return
-		default:
-			cg.addError(fmt.Sprintf("unknown func name %s", r.Name))
-		}
-
 	default:
-		cg.genEx(e.AssignedValue, rd)
+		// an element assignment evaluates the value itself, see genAssignArray
+		if _, element := e.Assigne.(ast.ArrayIndexEx); !element {
+			cg.genEx(e.AssignedValue, rd)
+		}
 	}
 
 	switch target := e.Assigne.(type) {
@@ -648,6 +936,9 @@ func (cg *CodeGenerator) genAssignEx(e ast.AssignmentExpr, rd isa.Register) {
 		cg.emitImmediate(symbol.AbsAddress)
 	case ast.ArrayIndexEx:
 		cg.genAssignArray(e, target)
+		if rd != -1 && rd != isa.RA {
+			cg.emitMov(isa.MvRegReg, rd, isa.RA, -1)
+		}
 
 	default:
 		cg.addError(fmt.Sprintf("Unsupported assignment target type: %T", target))
@@ -658,56 +949,76 @@ func (cg *CodeGenerator) genAssignArray(e ast.AssignmentExpr, target ast.ArrayIn
 	regWithAddr := isa.RAddr
 	regWithVal := isa.RA
 	cg.genEx(e.AssignedValue, regWithVal)
+	saveVal := cg.containsCall(target.Index)
+	if saveVal {
+		cg.emitPushReg(regWithVal)
+	}
 	cg.genArrayAddress(target, regWithAddr)
-	cg.emitInstruction(isa.OpMov, isa.MvLowRegToRegInd, regWithAddr, regWithVal, -1)
+	if saveVal {
+		cg.emitPopToReg(regWithVal)
+	}
+	cg.genStoreElement(elementSize(target.Target.StaticType()), regWithAddr, regWithVal)
 }
 
-// calculates addr of array element and stores it in rd
-func (cg *CodeGenerator) genArrayAddress(ix ast.ArrayIndexEx, rd isa.Register) {
-	cg.genEx(ix.Target, isa.RM1)
-	cg.genEx(ix.Index, isa.RM2)
+// genCompoundAssign generates `target op= value`, the new value is left in rd.
+// The operand is evaluated into RM2, the target is updated in RA.
+// A constant operand is applied with a single MathRIR instruction.
+func (cg *CodeGenerator) genCompoundAssign(e ast.AssignmentExpr, opcode uint32, rd isa.Register) {
+	regWithVal := isa.RA
+	imm, isImm := e.AssignedValue.(ast.NumberExpr)
+	applyOp := func(reg isa.Register) {
+		if isImm {
+			cg.emitInstruction(opcode, isa.MathRIR, reg, reg, -1)
+			cg.emitImmediate(uint32(imm.Value))
+			return
+		}
+		cg.emitInstruction(opcode, isa.MathRRR, reg, reg, isa.RM2)
+	}
 
-	cg.emitInstruction(isa.OpAdd, isa.MathRRR, rd, isa.RM1, isa.RM2)
+	switch target := e.Assigne.(type) {
+	case ast.SymbolExpr:
+		symbol, found := cg.lookupSymbol(target.Value)
+		if !found {
+			cg.addError(fmt.Sprintf("Undeclared variable '%s' used in assignment.", target.Value))
+			return
+		}
+		if !isImm {
+			cg.genEx(e.AssignedValue, isa.RM2)
+		}
+		cg.emitMov(isa.MvMemReg, regWithVal, isa.Register(symbol.AbsAddress), -1)
+		applyOp(regWithVal)
+		cg.emitMov(isa.MvRegMem, isa.Register(symbol.AbsAddress), regWithVal, -1)
+
+	case ast.ArrayIndexEx:
+		// the operand is evaluated first and kept on the stack, the element address is in RAddr
+		if !isImm {
+			cg.genEx(e.AssignedValue, isa.RM2)
+			cg.emitPushReg(isa.RM2)
+		}
+		cg.genArrayAddress(target, isa.RAddr)
+		if !isImm {
+			cg.emitPopToReg(isa.RM2)
+		}
+		size := elementSize(target.Target.StaticType())
+		cg.genLoadElement(size, regWithVal, isa.RAddr)
+		applyOp(regWithVal)
+		cg.genStoreElement(size, isa.RAddr, regWithVal)
+
+	default:
+		cg.addError(fmt.Sprintf("Unsupported assignment target type: %T", target))
+		return
+	}
+	if rd != -1 && rd != regWithVal {
+		cg.emitMov(isa.MvRegReg, rd, regWithVal, -1)
+	}
 }
 
 func (cg *CodeGenerator) genIfStmt(s ast.IfStmt) {
 	cg.debugAssembly = append(cg.debugAssembly, "IF STATEMENT CONDITION:")
-	var operator lexer.Token
-	switch a := s.Condition.(type) {
-	case ast.BinaryExpr:
-		operator = a.Operator
-	case ast.SymbolExpr:
-		cg.addError(fmt.Sprintf("checking for variable bool is not implemented - %v", a.Value))
-	case ast.NumberExpr:
-		cg.addError(fmt.Sprintf("just number in if condition - %v", a.Value))
-	}
-
-	cg.genEx(s.Condition, -1) // cmp generates inside, flags must be set
-
-	// determines type of jump based on operator in reverse order (> -> jump less_equals to else block)
-	var jmpToAltOpc uint32
-	switch operator.Kind {
-	case lexer.EQUALS:
-		jmpToAltOpc = isa.OpJne
-	case lexer.NotEquals:
-		jmpToAltOpc = isa.OpJe
-	case lexer.GREATER:
-		jmpToAltOpc = isa.OpJle
-	case lexer.LESS:
-		jmpToAltOpc = isa.OpJge
-	case lexer.GreaterEquals:
-		jmpToAltOpc = isa.OpJl
-	case lexer.LessEquals:
-		jmpToAltOpc = isa.OpJg
-	default:
-		jmpToAltOpc = isa.OpJmp
-	}
-	cg.emitInstruction(jmpToAltOpc, isa.JAbsAddr, -1, -1, -1)
-	addrToPatchElse := cg.nextInstructionAddr
-	cg.emitImmediate(0)
+	falsePatches := cg.genJumpIfFalse(s.Condition)
 
 	cg.debugAssembly = append(cg.debugAssembly, "IF STMT CONSEQUENCE:")
-	cg.generateStmt(s.Consequent)
+	cg.generateBody(s.Consequent, "if")
 
 	var addrOfAddrToJumpAfterElse uint32 = 4294967295
 	if s.Alternate != nil {
@@ -719,12 +1030,12 @@ func (cg *CodeGenerator) genIfStmt(s ast.IfStmt) {
 
 	if s.Alternate != nil {
 		cg.debugAssembly = append(cg.debugAssembly, "IF STMT ALTERNATE:")
-		cg.generateStmt(s.Alternate)
+		cg.generateBody(s.Alternate, "else")
 	}
 
 	endOfElseAddr := cg.nextInstructionAddr
 
-	cg.PatchWord(addrToPatchElse, elseBlockAddr)
+	cg.patchJumps(falsePatches, elseBlockAddr)
 	if s.Alternate != nil {
 		if addrOfAddrToJumpAfterElse == 4294967295 {
 			panic("addr defined not gud")
@@ -750,35 +1061,7 @@ func (cg *CodeGenerator) genStringEx(e ast.StringExpr, rd isa.Register) {
 	cg.emitImmediate(stringAddr)
 }
 
-func (cg *CodeGenerator) genAddLongAssign(args []ast.Expr, target ast.SymbolExpr) {
-	addrA := cg.FindSymbol(args[0].(ast.SymbolExpr)).AbsAddress
-	addrB := cg.FindSymbol(args[1].(ast.SymbolExpr)).AbsAddress
-	addrT := cg.FindSymbol(target).AbsAddress
-
-	cg.emitMov(isa.MvImmReg, isa.RM1, isa.Register(addrA), -1)
-	cg.emitMov(isa.MvImmReg, isa.RM2, isa.Register(addrB), -1)
-
-	cg.emitMov(isa.MvRegIndToReg, isa.RT, isa.RM1, -1)
-	cg.emitMov(isa.MvRegIndToReg, isa.R6, isa.RM2, -1)
-	cg.emitInstruction(isa.OpAdd, isa.MathRRR, isa.RA, isa.RT, isa.R6)
-
-	cg.emitInstruction(isa.OpAdd, isa.MathRIR, isa.RM1, isa.RM1, -1)
-	cg.emitImmediate(4)
-	cg.emitInstruction(isa.OpAdd, isa.MathRIR, isa.RM2, isa.RM2, -1)
-	cg.emitImmediate(4)
-
-	cg.emitMov(isa.MvRegIndToReg, isa.RT, isa.RM1, -1)
-	cg.emitMov(isa.MvRegIndToReg, isa.R6, isa.RM2, -1)
-	cg.emitInstruction(isa.OpAdd, isa.MathRRR, isa.RT2, isa.RT, isa.R6)
-
-	cg.emitInstruction(isa.OpJcc, isa.JAbsAddr, -1, -1, -1)
-	skip := cg.ReserveWord()
-	cg.emitInstruction(isa.OpAdd, isa.MathRIR, isa.RT2, isa.RT2, -1)
-	cg.emitImmediate(1)
-	cg.PatchWord(skip, cg.nextInstructionAddr)
-
-	cg.emitInstruction(isa.OpMov, isa.MvRegMem, -1, isa.RA, -1)
-	cg.emitImmediate(addrT)
-	cg.emitInstruction(isa.OpMov, isa.MvRegMem, -1, isa.RT2, -1)
-	cg.emitImmediate(addrT + 4)
+func isSymbol(e ast.Expr) bool {
+	_, ok := e.(ast.SymbolExpr)
+	return ok
 }
@@ -5,7 +5,9 @@ import (
 	"fmt"
 
 	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
+	"github.com/awesoma31/csa-lab4/pkg/translator/diag"
 	"github.com/awesoma31/csa-lab4/pkg/translator/isa"
+	"github.com/awesoma31/csa-lab4/pkg/translator/lexer"
 )
 
 // Constants for memory management and interrupt handling.
@@ -13,6 +15,7 @@ const (
 	WordSizeBytes          = 4
 	intVectorTableBaseAddr = 0
 	maxInterrupts          = 2
+	trapVectorBaseAddr     = intVectorTableBaseAddr + maxInterrupts
 	maxStringLength        = 255 // Max characters for Pascal-style string
 )
 
@@ -31,11 +34,14 @@ type SymbolEntry struct {
 	IsStr       bool
 	IsRead      bool // Indicates if the symbol has been read/used
 	IsLong      bool // Indicates if the symbol is a 64-bit integer
+	IsBool      bool // Declared from true/false, print shows it as a word
 }
 
 // Scope manages a collection of symbols within a particular scope.
 type Scope struct {
 	symbols map[string]SymbolEntry
+	name    string // What opened the scope (global, if, while, fn name, ...)
+	depth   int    // Nesting level, the global scope is 0
 }
 
 // Symbols returns the map of symbols in the current scope.
@@ -43,6 +49,16 @@ func (sc *Scope) Symbols() map[string]SymbolEntry {
 	return sc.symbols
 }
 
+// Name returns the label of the construct that opened the scope.
+func (sc *Scope) Name() string {
+	return sc.name
+}
+
+// Depth returns the nesting level of the scope, the global scope is 0.
+func (sc *Scope) Depth() int {
+	return sc.depth
+}
+
 // --- Code Generator Core ---
 
 // CodeGenerator handles the translation of AST into machine code and data.
@@ -51,26 +67,52 @@ type CodeGenerator struct {
 	dataMemory        []byte   // Machine bytes for data memory
 	debugAssembly     []string // Assembly mnemonics with addresses for debugging
 
-	scopeStack          []Scope  // Stack of scopes for symbol resolution
-	nextInstructionAddr uint32   // Next free address in instruction memory (word-addresses)
-	nextDataAddr        uint32   // Next free address in data memory (byte-addresses)
-	heapPtrAddr         uint32   // Address of the heap pointer in data memory
-	errors              []string // List of errors encountered during code generation
+	scopeStack          []Scope      // Stack of scopes for symbol resolution
+	scopes              []Scope      // Every scope ever opened, in order of opening (for dumps)
+	nextInstructionAddr uint32       // Next free address in instruction memory (word-addresses)
+	nextDataAddr        uint32       // Next free address in data memory (byte-addresses)
+	heapPtrAddr         uint32       // Address of the heap pointer in data memory
+	errors              []diag.Error // List of errors encountered during code generation
+	source              diag.Source  // Program text used to render errors, see SetSource
+	pos                 lexer.Pos    // Position of the node being generated, errors are reported here
+
+	functions    map[string]*functionInfo // Declared user functions by name
+	functionList []*functionInfo          // Declared user functions in source order
+	currentFn    *functionInfo            // Function whose body is being generated, nil at top level
+	callSites    []callSite               // CALL operands resolved after all functions are generated
+
+	loops []*loopContext // Enclosing loops, innermost last, targets of break/continue
+
+	longScratchAddr uint32    // Long used to pass values to and from the long port, see longScratch
+	boolNames       [2]uint32 // Addresses of "false" and "true" for print, allocated on first use
+
+	allocCalls   []uint32 // CALL operands of alloc(n), resolved by genAllocRoutine
+	freeListAddr uint32   // Head of the list of freed heap blocks, allocated on first use
+	heapStart    uint32   // Start of the heap reserved for alloc, see reserveHeap
+
+	opts Options // Code generation switches, see SetOptions
+}
+
+// loopContext collects break and continue jumps of a loop until its exit and continue addresses are known.
+type loopContext struct {
+	breakPatches    []uint32
+	continuePatches []uint32
 }
 
 // NewCodeGenerator creates and initializes a new CodeGenerator.
 func NewCodeGenerator() *CodeGenerator {
-	// Reserve space for interrupt vector table
-	instructionMemory := make([]uint32, maxInterrupts)
+	// Reserve space for interrupt and trap vector tables
+	instructionMemory := make([]uint32, maxInterrupts+isa.TrapCount)
 
 	cg := &CodeGenerator{
 		instructionMemory:   instructionMemory,
 		dataMemory:          make([]byte, 0),
 		debugAssembly:       make([]string, 0),
 		scopeStack:          make([]Scope, 0),
-		nextInstructionAddr: intVectorTableBaseAddr + maxInterrupts, // Start after vector table
+		nextInstructionAddr: trapVectorBaseAddr + isa.TrapCount, // Start after vector tables
 		nextDataAddr:        0,
-		errors:              make([]string, 0),
+		errors:              make([]diag.Error, 0),
+		functions:           make(map[string]*functionInfo),
 	}
 	// Initialize heap pointer in data memory
 	cg.heapPtrAddr = cg.addNumberData(0) // Allocate space for initial heap pointer (0)
@@ -87,18 +129,52 @@ func (cg *CodeGenerator) ScopeStack() []Scope {
 	return cg.scopeStack
 }
 
+// Scopes returns every scope opened during generation in order of opening,
+// so that nested scopes directly follow their parent.
+func (cg *CodeGenerator) Scopes() []Scope {
+	return cg.scopes
+}
+
+// SetSource sets the file name and text of the program, so that errors
+// are reported as file:line:col with an excerpt of the source line.
+func (cg *CodeGenerator) SetSource(file, src string) {
+	cg.source = diag.Source{File: file, Text: src}
+}
+
+// Options are code generation switches, the zero value is the default.
+type Options struct {
+	// BranchCarry propagates the carry of long arithmetic with JCC/JCS
+	// branches instead of ADC/SBC, to compare both in tick counts.
+	BranchCarry bool
+	// BoundsCheck checks every array index against the length in the array
+	// header, an index out of range stops the machine with a trap.
+	BoundsCheck bool
+	// HeapSize is the size in bytes of the heap reserved for alloc at the end
+	// of the data image, DefaultHeapSize if 0. Programs without alloc get none.
+	HeapSize int
+}
+
+// SetOptions sets the code generation switches, it must be called before Generate.
+func (cg *CodeGenerator) SetOptions(opts Options) {
+	cg.opts = opts
+}
+
 // Generate starts the code generation process from the AST.
 // It returns the instruction memory, data memory, debug assembly, and any errors.
 func (cg *CodeGenerator) Generate(program ast.BlockStmt) ([]uint32, []byte, []string, []string) {
 	cg.VisitProgram(&program) // Assuming VisitProgram is the entry point for AST traversal
 
-	// After code generation, update the heap pointer in data memory
+	// After code generation, update the heap pointer in data memory,
+	// it points at the heap reserved for alloc or at the end of the image
 	heapStart := cg.nextDataAddr
+	if len(cg.allocCalls) != 0 {
+		heapStart = cg.heapStart
+	}
 	binary.LittleEndian.PutUint32(
 		cg.dataMemory[cg.heapPtrAddr:cg.heapPtrAddr+WordSizeBytes],
 		heapStart,
 	)
-	return cg.instructionMemory, cg.dataMemory, cg.debugAssembly, cg.errors
+	return cg.instructionMemory, cg.dataMemory, cg.debugAssembly, cg.GetErrors()
 }
 
 // currentScope returns a pointer to the topmost scope on the stack.
@@ -123,8 +199,32 @@ func (cg *CodeGenerator) lookupSymbol(name string) (SymbolEntry, bool) {
 }
 
 // addSymbolToScope adds a symbol entry to the current scope.
+// Redeclaration in the same scope is an error, shadowing an outer one is allowed.
+// Symbols declared inside a function body become part of its frame.
 func (cg *CodeGenerator) addSymbolToScope(entry SymbolEntry) {
+	if cg.declaredInCurrentScope(entry.Name) {
+		return
+	}
 	cg.currentScope().symbols[entry.Name] = entry
+	if cg.currentFn != nil && entry.MemoryArea == "data" {
+		cg.currentFn.addFrameSlots(entry.AbsAddress, entry.SizeInBytes)
+	}
+}
+
+// inBlock reports whether code is generated inside a block rather than at the top level,
+// where a statement may run more than once.
+func (cg *CodeGenerator) inBlock() bool {
+	return len(cg.scopeStack) > 1
+}
+
+// declaredInCurrentScope reports a redeclaration error if name already exists in the current scope.
+func (cg *CodeGenerator) declaredInCurrentScope(name string) bool {
+	sc := cg.currentScope()
+	if _, found := sc.symbols[name]; found {
+		cg.addError(fmt.Sprintf("Variable '%s' already declared in this scope (%s, depth %d)", name, sc.name, sc.depth))
+		return true
+	}
+	return false
 }
 
 // --- Instruction Emission ---
@@ -184,6 +284,8 @@ func (cg *CodeGenerator) emitMov(mode uint32, dest, s1, s2 isa.Register) {
 		cg.emitInstruction(isa.OpMov, isa.MvRegToRegInd, dest, s1, -1)
 	case isa.MvByteRegIndToReg: // byte from reg indexed to reg: MOV.B D, [S1]
 		cg.emitInstruction(isa.OpMov, isa.MvByteRegIndToReg, dest, s1, -1)
+	case isa.MvCauseReg: // trap cause to reg: MOV D, CAUSE
+		cg.emitInstruction(isa.OpMov, isa.MvCauseReg, dest, -1, -1)
 	case isa.MvRegLowToMem: // low part of reg to mem: MOV.L [D], S1
 		cg.emitInstruction(isa.OpMov, isa.MvRegLowToMem, -1, s1, -1)
 		cg.emitImmediate(uint32(dest))
@@ -246,6 +348,15 @@ func (cg *CodeGenerator) addString(s string) uint32 {
 	return strStartAddr
 }
 
+// addStringBuffer adds an empty Pascal-style string with room for capacity characters.
+func (cg *CodeGenerator) addStringBuffer(capacity int) uint32 {
+	strStartAddr := cg.addString("")
+	cg.dataMemory = append(cg.dataMemory, make([]byte, capacity)...)
+	cg.nextDataAddr += uint32(capacity)
+	cg.alignDataMemory()
+	return strStartAddr
+}
+
 // addLongData adds a 64-bit integer to data memory.
 // It stores two 32-bit words (low part then high part).
 // Returns the address of the low part.
@@ -286,26 +397,43 @@ func (cg *CodeGenerator) alignDataMemory() {
 
 // --- Error Handling ---
 
-// addError appends an error message to the internal error list.
+// addError appends an error message at the position of the node being generated.
 func (cg *CodeGenerator) addError(msg string) {
-	cg.errors = append(cg.errors, msg)
+	cg.errors = append(cg.errors, diag.Error{Pos: cg.pos, Msg: msg})
+}
+
+// enter makes node the position of errors until the returned function is called:
+//
+//	defer cg.enter(expr)()
+func (cg *CodeGenerator) enter(node interface{ Position() lexer.Pos }) func() {
+	saved := cg.pos
+	if node != nil && node.Position().IsValid() {
+		cg.pos = node.Position()
+	}
+	return func() { cg.pos = saved }
 }
 
 // --- Scope Stack Management ---
 
-// pushScope adds a new empty scope to the scope stack.
-func (cg *CodeGenerator) pushScope() {
-	cg.scopeStack = append(cg.scopeStack, Scope{symbols: make(map[string]SymbolEntry)})
+// pushScope adds a new empty scope named after the construct that opens it to the scope stack.
+func (cg *CodeGenerator) pushScope(name string) {
+	sc := Scope{
+		symbols: make(map[string]SymbolEntry),
+		name:    name,
+		depth:   len(cg.scopeStack),
+	}
+	cg.scopeStack = append(cg.scopeStack, sc)
+	cg.scopes = append(cg.scopes, sc)
 }
 
 // popScope removes the top scope from the scope stack.
 // Panics if the scope stack is empty.
-// func (cg *CodeGenerator) popScope() {
-// 	if len(cg.scopeStack) == 0 {
-// 		panic("scope stack is empty: cannot pop scope")
-// 	}
-// 	cg.scopeStack = cg.scopeStack[:len(cg.scopeStack)-1]
-// }
+func (cg *CodeGenerator) popScope() {
+	if len(cg.scopeStack) == 0 {
+		panic("scope stack is empty: cannot pop scope")
+	}
+	cg.scopeStack = cg.scopeStack[:len(cg.scopeStack)-1]
+}
 
 // --- Getters for Generated Output ---
 
@@ -324,7 +452,7 @@ func (cg *CodeGenerator) GetDebugAssembly() []string {
 	return cg.debugAssembly
 }
 
-// GetErrors returns any errors encountered during code generation.
+// GetErrors returns any errors encountered during code generation, formatted with their position.
 func (cg *CodeGenerator) GetErrors() []string {
-	return cg.errors
+	return cg.source.FormatAll(cg.errors)
 }
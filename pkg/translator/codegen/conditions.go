@@ -0,0 +1,159 @@
+package codegen
+
+import (
+	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
+	"github.com/awesoma31/csa-lab4/pkg/translator/isa"
+	"github.com/awesoma31/csa-lab4/pkg/translator/lexer"
+)
+
+// Conditions are compiled to control flow rather than values: a condition
+// produces a list of jump operands that still have to be patched with the
+// target address. `&&` and `||` are short-circuit - the right operand is not
+// evaluated when the left one already decides the result:
+//
+//	a && b, jump if false:   a, jump if false -> F
+//	                         b, jump if false -> F
+//	a || b, jump if false:   a, jump if true  -> T
+//	                         b, jump if false -> F
+//	                      T:
+//
+// `!a` swaps the two cases. Any other expression is a truthiness test:
+// non-zero is true.
+
+// relationalJumps maps a relational operator to the jump taken when it holds.
+var relationalJumps = map[lexer.TokenKind]uint32{
+	lexer.EQUALS:        isa.OpJe,
+	lexer.NotEquals:     isa.OpJne,
+	lexer.GREATER:       isa.OpJg,
+	lexer.LESS:          isa.OpJl,
+	lexer.GreaterEquals: isa.OpJge,
+	lexer.LessEquals:    isa.OpJle,
+}
+
+// invertedJumps maps a conditional jump to the one taken in the opposite case.
+var invertedJumps = map[uint32]uint32{
+	isa.OpJe:  isa.OpJne,
+	isa.OpJne: isa.OpJe,
+	isa.OpJg:  isa.OpJle,
+	isa.OpJle: isa.OpJg,
+	isa.OpJl:  isa.OpJge,
+	isa.OpJge: isa.OpJl,
+	isa.OpJa:  isa.OpJbe,
+	isa.OpJbe: isa.OpJa,
+	isa.OpJb:  isa.OpJae,
+	isa.OpJae: isa.OpJb,
+}
+
+// genJumpIfFalse generates cond and jumps away when it is false, falling through otherwise.
+// Returns the jump operands to patch with the false target.
+func (cg *CodeGenerator) genJumpIfFalse(cond ast.Expr) []uint32 {
+	switch e := cond.(type) {
+	case ast.BoolExpr:
+		if e.Value {
+			return nil
+		}
+		return []uint32{cg.emitJump(isa.OpJmp)}
+	case ast.BinaryExpr:
+		switch e.Operator.Kind {
+		case lexer.AND:
+			patches := cg.genJumpIfFalse(e.Left)
+			return append(patches, cg.genJumpIfFalse(e.Right)...)
+		case lexer.OR:
+			truePatches := cg.genJumpIfTrue(e.Left)
+			patches := cg.genJumpIfFalse(e.Right)
+			cg.patchJumps(truePatches, cg.nextInstructionAddr)
+			return patches
+		}
+		if jmp, ok := relationalJump(e); ok {
+			if isLong(e.Left) || isLong(e.Right) {
+				return cg.genLongJump(e, false)
+			}
+			cg.genEx(e, -1)
+			return []uint32{cg.emitJump(invertedJumps[jmp])}
+		}
+	case ast.PrefixExpr:
+		if e.Operator.Kind == lexer.NOT {
+			return cg.genJumpIfTrue(e.Right)
+		}
+	}
+	return []uint32{cg.genTruthTest(cond, isa.OpJe)}
+}
+
+// genJumpIfTrue generates cond and jumps away when it is true, falling through otherwise.
+// Returns the jump operands to patch with the true target.
+func (cg *CodeGenerator) genJumpIfTrue(cond ast.Expr) []uint32 {
+	switch e := cond.(type) {
+	case ast.BoolExpr:
+		if !e.Value {
+			return nil
+		}
+		return []uint32{cg.emitJump(isa.OpJmp)}
+	case ast.BinaryExpr:
+		switch e.Operator.Kind {
+		case lexer.AND:
+			falsePatches := cg.genJumpIfFalse(e.Left)
+			patches := cg.genJumpIfTrue(e.Right)
+			cg.patchJumps(falsePatches, cg.nextInstructionAddr)
+			return patches
+		case lexer.OR:
+			patches := cg.genJumpIfTrue(e.Left)
+			return append(patches, cg.genJumpIfTrue(e.Right)...)
+		}
+		if jmp, ok := relationalJump(e); ok {
+			if isLong(e.Left) || isLong(e.Right) {
+				return cg.genLongJump(e, true)
+			}
+			cg.genEx(e, -1)
+			return []uint32{cg.emitJump(jmp)}
+		}
+	case ast.PrefixExpr:
+		if e.Operator.Kind == lexer.NOT {
+			return cg.genJumpIfFalse(e.Right)
+		}
+	}
+	return []uint32{cg.genTruthTest(cond, isa.OpJne)}
+}
+
+// genTruthTest compares the value of expr with zero and emits jmp, returns its operand address.
+func (cg *CodeGenerator) genTruthTest(expr ast.Expr, jmp uint32) uint32 {
+	cg.genEx(expr, isa.RM1)
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, isa.RM1, isa.ZERO)
+	return cg.emitJump(jmp)
+}
+
+// isBoolOperator reports whether op yields a condition rather than a number.
+func isBoolOperator(op lexer.TokenKind) bool {
+	_, relational := relationalJumps[op]
+	return relational || op == lexer.AND || op == lexer.OR || op == lexer.NOT
+}
+
+// boolWord returns the stored value of a bool: 1 for true, 0 for false.
+func boolWord(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// genBoolValue materializes a condition as a value in rd: 1 if it holds, 0 otherwise.
+func (cg *CodeGenerator) genBoolValue(cond ast.Expr, rd isa.Register) {
+	falsePatches := cg.genJumpIfFalse(cond)
+	cg.emitMov(isa.MvImmReg, rd, 1, -1)
+	endPatch := cg.emitJump(isa.OpJmp)
+	cg.patchJumps(falsePatches, cg.nextInstructionAddr)
+	cg.emitMov(isa.MvImmReg, rd, 0, -1)
+	cg.PatchWord(endPatch, cg.nextInstructionAddr)
+}
+
+// emitJump emits a (conditional) jump with a reserved operand and returns the operand address.
+func (cg *CodeGenerator) emitJump(opcode uint32) uint32 {
+	cg.emitInstruction(opcode, isa.JAbsAddr, -1, -1, -1)
+	return cg.ReserveWord()
+}
+
+// patchJumps points every reserved jump operand to target.
+func (cg *CodeGenerator) patchJumps(patches []uint32, target uint32) {
+	for _, addr := range patches {
+		cg.PatchWord(addr, target)
+	}
+}
@@ -0,0 +1,231 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
+	"github.com/awesoma31/csa-lab4/pkg/translator/isa"
+)
+
+// Calling convention
+//
+// Variables are allocated statically, so every function owns a fixed set of
+// data-memory words (parameters and locals) - its frame slots. To make
+// recursion work the callee saves the previous contents of its slots on the
+// stack on entry and restores them before returning:
+//
+//	caller:   PUSH arg0 ... PUSH argN-1   ; arguments left to right
+//	          CALL fn                     ; pushes the return address
+//	          ADD  SP, SP, #4*N           ; drops the arguments
+//	callee:   PUSH slot0 ... PUSH slotK-1 ; saves the outer activation
+//	          slot(param i) <- mem[SP + 4*K + 4 + 4*(N-1-i)]
+//	          ...body...                  ; the result is left in RA
+//	          POP slotK-1 ... POP slot0
+//	          RET
+//
+// Stack frame (grows down):
+//
+//	+----------------+
+//	| arg0           |
+//	|  ...           |
+//	| argN-1         |
+//	| return address |
+//	| saved slot0    |
+//	|  ...           |
+//	| saved slotK-1  | <- SP
+//	+----------------+
+//
+// The slots are only known after the body is generated, so the prologue is
+// emitted after the epilogue and the entry point jumps to it.
+
+// functionInfo describes a user function during code generation.
+type functionInfo struct {
+	decl          ast.FunctionDeclarationStmt
+	entryAddr     uint32   // address of the first instruction of the function
+	frameSlots    []uint32 // data-memory words saved on entry and restored on exit
+	returnPatches []uint32 // operands of `return` jumps to patch with the epilogue address
+}
+
+// callSite is an unresolved CALL operand.
+type callSite struct {
+	name string
+	addr uint32
+}
+
+func (fn *functionInfo) addFrameSlots(addr uint32, size int) {
+	for off := 0; off < size; off += WordSizeBytes {
+		fn.frameSlots = append(fn.frameSlots, addr+uint32(off))
+	}
+}
+
+// declareFunction registers a function so it can be called before its body is generated.
+func (cg *CodeGenerator) declareFunction(s ast.FunctionDeclarationStmt) {
+	defer cg.enter(s)()
+	if _, found := cg.functions[s.Name]; found {
+		cg.addError(fmt.Sprintf("Function '%s' already declared", s.Name))
+		return
+	}
+	fn := &functionInfo{decl: s}
+	cg.functions[s.Name] = fn
+	cg.functionList = append(cg.functionList, fn)
+}
+
+// genFunctions generates bodies of all declared functions and resolves call sites.
+func (cg *CodeGenerator) genFunctions() {
+	for _, fn := range cg.functionList {
+		cg.genFunctionDecl(fn)
+	}
+
+	for _, site := range cg.callSites {
+		fn, found := cg.functions[site.name]
+		if !found {
+			continue
+		}
+		cg.PatchWord(site.addr, fn.entryAddr)
+	}
+}
+
+func (cg *CodeGenerator) genFunctionDecl(fn *functionInfo) {
+	defer cg.enter(fn.decl)()
+	cg.debugAssembly = append(cg.debugAssembly, fmt.Sprintf("FUNCTION %s", fn.decl.Name))
+	fn.entryAddr = cg.nextInstructionAddr
+
+	cg.emitInstruction(isa.OpJmp, isa.JAbsAddr, -1, -1, -1)
+	prologuePatch := cg.ReserveWord()
+	bodyAddr := cg.nextInstructionAddr
+
+	cg.currentFn = fn
+	cg.pushScope("fn " + fn.decl.Name)
+
+	params := make([]uint32, 0, len(fn.decl.Parameters))
+	for _, param := range fn.decl.Parameters {
+		if cg.declaredInCurrentScope(param.Name) {
+			continue
+		}
+		addr := cg.addNumberData(0)
+		params = append(params, addr)
+		cg.addSymbolToScope(SymbolEntry{
+			Name:        param.Name,
+			Type:        ast.IntType,
+			MemoryArea:  "data",
+			AbsAddress:  addr,
+			SizeInBytes: WordSizeBytes,
+		})
+	}
+
+	cg.debugAssembly = append(cg.debugAssembly, "FUNCTION BODY:")
+	for _, stmt := range fn.decl.Body {
+		cg.generateStmt(stmt)
+	}
+	// falling off the end returns 0
+	cg.emitMov(isa.MvImmReg, isa.RA, 0, -1)
+
+	cg.debugAssembly = append(cg.debugAssembly, "FUNCTION EPILOGUE:")
+	epilogueAddr := cg.nextInstructionAddr
+	for _, patch := range fn.returnPatches {
+		cg.PatchWord(patch, epilogueAddr)
+	}
+	for i := len(fn.frameSlots) - 1; i >= 0; i-- {
+		cg.emitPopToReg(isa.RM1)
+		cg.emitMov(isa.MvRegMem, isa.Register(fn.frameSlots[i]), isa.RM1, -1)
+	}
+	cg.emitInstruction(isa.OpRet, isa.NoOperands, -1, -1, -1)
+
+	cg.debugAssembly = append(cg.debugAssembly, "FUNCTION PROLOGUE:")
+	cg.PatchWord(prologuePatch, cg.nextInstructionAddr)
+	for _, slot := range fn.frameSlots {
+		cg.emitMov(isa.MvMemReg, isa.RM1, isa.Register(slot), -1)
+		cg.emitPushReg(isa.RM1)
+	}
+	savedBytes := uint32(len(fn.frameSlots) * WordSizeBytes)
+	for i, addr := range params {
+		argOffset := savedBytes + WordSizeBytes + uint32((len(params)-1-i)*WordSizeBytes)
+		cg.emitMov(isa.MvRegReg, isa.RAddr, isa.SpReg, -1)
+		cg.emitInstruction(isa.OpAdd, isa.MathRIR, isa.RAddr, isa.RAddr, -1)
+		cg.emitImmediate(argOffset)
+		cg.emitMov(isa.MvRegIndToReg, isa.RM1, isa.RAddr, -1)
+		cg.emitMov(isa.MvRegMem, isa.Register(addr), isa.RM1, -1)
+	}
+	cg.emitInstruction(isa.OpJmp, isa.JAbsAddr, -1, -1, -1)
+	cg.emitImmediate(bodyAddr)
+
+	cg.popScope()
+	cg.currentFn = nil
+	cg.debugAssembly = append(cg.debugAssembly, fmt.Sprintf(" # END OF FUNCTION %s", fn.decl.Name))
+}
+
+func (cg *CodeGenerator) genReturnStmt(s ast.ReturnStmt) {
+	if cg.currentFn == nil {
+		cg.addError("return statement outside of function")
+		return
+	}
+	cg.debugAssembly = append(cg.debugAssembly, "RETURN STMT")
+	if s.Expr != nil {
+		cg.genEx(s.Expr, isa.RA)
+	} else {
+		cg.emitMov(isa.MvImmReg, isa.RA, 0, -1)
+	}
+	cg.emitInstruction(isa.OpJmp, isa.JAbsAddr, -1, -1, -1)
+	cg.currentFn.returnPatches = append(cg.currentFn.returnPatches, cg.ReserveWord())
+}
+
+// genCallEx generates a call of a user function, leaving its result in rd.
+func (cg *CodeGenerator) genCallEx(e ast.CallExpr, rd isa.Register) {
+	fn, found := cg.functions[e.Name]
+	if !found {
+		cg.addError(fmt.Sprintf("unknown func name %s", e.Name))
+		return
+	}
+	if len(e.Args) != len(fn.decl.Parameters) {
+		cg.addError(fmt.Sprintf("function '%s' expects %d arguments, got %d", e.Name, len(fn.decl.Parameters), len(e.Args)))
+		return
+	}
+
+	cg.debugAssembly = append(cg.debugAssembly, fmt.Sprintf("CALL %s", e.Name))
+	for _, arg := range e.Args {
+		cg.genEx(arg, isa.RA)
+		cg.emitPushReg(isa.RA)
+	}
+
+	cg.emitInstruction(isa.OpCall, isa.JAbsAddr, -1, -1, -1)
+	cg.callSites = append(cg.callSites, callSite{name: e.Name, addr: cg.ReserveWord()})
+
+	if len(e.Args) > 0 {
+		cg.emitInstruction(isa.OpAdd, isa.MathRIR, isa.SpReg, isa.SpReg, -1)
+		cg.emitImmediate(uint32(len(e.Args) * WordSizeBytes))
+	}
+	if rd != -1 && rd != isa.RA {
+		cg.emitMov(isa.MvRegReg, rd, isa.RA, -1)
+	}
+}
+
+// genLocalInit stores the initial value of a variable declared inside a block at runtime,
+// since the statically allocated word is shared by all activations and loop iterations.
+func (cg *CodeGenerator) genLocalInit(addr uint32, value uint32) {
+	cg.emitMov(isa.MvImmReg, isa.RA, isa.Register(value), -1)
+	cg.emitMov(isa.MvRegMem, isa.Register(addr), isa.RA, -1)
+}
+
+// containsCall reports whether evaluating expr may call a user function or the
+// allocator or divide uints, which clobbers every general purpose register except SP.
+func (cg *CodeGenerator) containsCall(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case ast.AllocExpr:
+		return true
+	case ast.CallExpr:
+		_, user := cg.functions[e.Name]
+		return user
+	case ast.BinaryExpr:
+		return isUnsignedDiv(e) || cg.containsCall(e.Left) || cg.containsCall(e.Right)
+	case ast.PrefixExpr:
+		return cg.containsCall(e.Right)
+	case ast.ArrayIndexEx:
+		return cg.containsCall(e.Target) || cg.containsCall(e.Index)
+	case ast.AssignmentExpr:
+		return cg.containsCall(e.Assigne) || cg.containsCall(e.AssignedValue)
+	case ast.TernaryExpr:
+		return cg.containsCall(e.Condition) || cg.containsCall(e.Consequent) || cg.containsCall(e.Alternate)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,153 @@
+package codegen
+
+import (
+	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
+	"github.com/awesoma31/csa-lab4/pkg/translator/isa"
+)
+
+// Heap
+//
+// alloc(n) takes an array of n elements from the heap at runtime, free(p)
+// gives it back. The heap is a region of Options.HeapSize bytes reserved at
+// the end of the data image when the program uses alloc, the stack starts
+// after the image, so the two never overlap. The word at heapPtrAddr keeps
+// the top of the heap. A block has two header words, the second one is the
+// array header, so an allocated block is indexed, iterated and bounds
+// checked like any other array:
+//
+//	[capacity][size][data ...]
+//	                 ^ the array value
+//
+// capacity is the size of the data rounded up to a word, size is the number
+// of bytes asked for. Freed blocks are kept in a list whose head is a data
+// word, a free block keeps the next one in its first data word. alloc takes
+// the first free block that is big enough, or bumps the top; it returns 0 if
+// n is not positive or the block would pass the end of the heap.
+//
+// The allocator is a routine generated once after the functions, alloc(n)
+// calls it with the size in bytes in RA and gets the array in RA.
+
+// DefaultHeapSize is the size of the heap in bytes if Options.HeapSize is not set.
+const DefaultHeapSize = 0x100
+
+// allocHeaderBytes is the size of the block header, capacity and size.
+const allocHeaderBytes = WordSizeBytes * 2
+
+// genAllocEx generates alloc(n), leaving the array in rd.
+func (cg *CodeGenerator) genAllocEx(e ast.AllocExpr, rd isa.Register) {
+	cg.genEx(e.Size, isa.RA)
+	for size := elementSize(e.StaticType()); size > 1; size >>= 1 {
+		cg.emitInstruction(isa.OpAdd, isa.MathRRR, isa.RA, isa.RA, isa.RA)
+	}
+	cg.allocCalls = append(cg.allocCalls, cg.emitJump(isa.OpCall))
+	if rd != -1 && rd != isa.RA {
+		cg.emitMov(isa.MvRegReg, rd, isa.RA, -1)
+	}
+}
+
+// genFreeStmt puts the block of an array taken by alloc at the head of the free list,
+// free of 0 (a failed alloc) does nothing.
+func (cg *CodeGenerator) genFreeStmt(s ast.FreeStmt) {
+	head := isa.Register(cg.freeListHead())
+	cg.genEx(s.Target, isa.RA)
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, isa.RA, isa.ZERO)
+	skip := cg.emitJump(isa.OpJe)
+	cg.emitMov(isa.MvMemReg, isa.RM1, head, -1)
+	cg.emitMov(isa.MvRegToRegInd, isa.RA, isa.RM1, -1)
+	cg.emitMov(isa.MvRegMem, head, isa.RA, -1)
+	cg.PatchWord(skip, cg.nextInstructionAddr)
+}
+
+// freeListHead returns the address of the head of the free list, allocated on first use.
+func (cg *CodeGenerator) freeListHead() uint32 {
+	if cg.freeListAddr == 0 {
+		cg.freeListAddr = cg.addNumberData(0)
+	}
+	return cg.freeListAddr
+}
+
+// reserveHeap adds the heap to the end of the data image and returns the address after it.
+func (cg *CodeGenerator) reserveHeap() uint32 {
+	size := cg.opts.HeapSize
+	if size <= 0 {
+		size = DefaultHeapSize
+	}
+	size = (size + WordSizeBytes - 1) &^ (WordSizeBytes - 1)
+	cg.alignDataMemory()
+	cg.heapStart = cg.nextDataAddr
+	cg.dataMemory = append(cg.dataMemory, make([]byte, size)...)
+	cg.nextDataAddr += uint32(size)
+	return cg.nextDataAddr
+}
+
+// genAllocRoutine generates the allocator if alloc is used and resolves its call sites.
+//
+//	RA   bytes asked for, then the result
+//	RM1  capacity of the new block
+//	RAddr address of the link to the block being looked at, the heap end when bumping
+//	RM2  the block
+//	RD   scratch
+func (cg *CodeGenerator) genAllocRoutine() {
+	if len(cg.allocCalls) == 0 {
+		return
+	}
+	cg.debugAssembly = append(cg.debugAssembly, "FUNCTION alloc")
+	cg.patchJumps(cg.allocCalls, cg.nextInstructionAddr)
+	head := cg.freeListHead()
+	heapEnd := cg.reserveHeap()
+
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, isa.RA, isa.ZERO)
+	failPatches := []uint32{cg.emitJump(isa.OpJle)}
+	cg.emitInstruction(isa.OpAdd, isa.MathRIR, isa.RM1, isa.RA, -1)
+	cg.emitImmediate(WordSizeBytes - 1)
+	cg.emitInstruction(isa.OpAnd, isa.ImmReg, isa.RM1, isa.RM1, -1)
+	cg.emitImmediate(^uint32(WordSizeBytes - 1))
+
+	// first fit
+	cg.emitMov(isa.MvImmReg, isa.RAddr, isa.Register(head), -1)
+	loopAddr := cg.nextInstructionAddr
+	cg.emitMov(isa.MvRegIndToReg, isa.RM2, isa.RAddr, -1)
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, isa.RM2, isa.ZERO)
+	bump := cg.emitJump(isa.OpJe)
+	cg.emitInstruction(isa.OpSub, isa.MathRIR, isa.RD, isa.RM2, -1)
+	cg.emitImmediate(allocHeaderBytes)
+	cg.emitMov(isa.MvRegIndToReg, isa.RD, isa.RD, -1)
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, isa.RD, isa.RM1)
+	found := cg.emitJump(isa.OpJae)
+	cg.emitMov(isa.MvRegReg, isa.RAddr, isa.RM2, -1)
+	cg.emitInstruction(isa.OpJmp, isa.JAbsAddr, -1, -1, -1)
+	cg.emitImmediate(loopAddr)
+
+	cg.PatchWord(found, cg.nextInstructionAddr)
+	cg.emitMov(isa.MvRegIndToReg, isa.RD, isa.RM2, -1)
+	cg.emitMov(isa.MvRegToRegInd, isa.RAddr, isa.RD, -1)
+	done := cg.emitJump(isa.OpJmp)
+
+	// no free block fits, take a new one from the top
+	cg.PatchWord(bump, cg.nextInstructionAddr)
+	cg.emitMov(isa.MvMemReg, isa.RM2, isa.Register(cg.heapPtrAddr), -1)
+	cg.emitInstruction(isa.OpAdd, isa.MathRIR, isa.RM2, isa.RM2, -1)
+	cg.emitImmediate(allocHeaderBytes)
+	cg.emitInstruction(isa.OpAdd, isa.MathRRR, isa.RD, isa.RM2, isa.RM1)
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, isa.RD, isa.RM2)
+	failPatches = append(failPatches, cg.emitJump(isa.OpJb))
+	cg.emitMov(isa.MvImmReg, isa.RAddr, isa.Register(heapEnd), -1)
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, isa.RD, isa.RAddr)
+	failPatches = append(failPatches, cg.emitJump(isa.OpJa))
+	cg.emitMov(isa.MvRegMem, isa.Register(cg.heapPtrAddr), isa.RD, -1)
+	cg.emitInstruction(isa.OpSub, isa.MathRIR, isa.RAddr, isa.RM2, -1)
+	cg.emitImmediate(allocHeaderBytes)
+	cg.emitMov(isa.MvRegToRegInd, isa.RAddr, isa.RM1, -1)
+
+	cg.PatchWord(done, cg.nextInstructionAddr)
+	cg.emitInstruction(isa.OpSub, isa.MathRIR, isa.RAddr, isa.RM2, -1)
+	cg.emitImmediate(WordSizeBytes)
+	cg.emitMov(isa.MvRegToRegInd, isa.RAddr, isa.RA, -1)
+	cg.emitMov(isa.MvRegReg, isa.RA, isa.RM2, -1)
+	cg.emitInstruction(isa.OpRet, isa.NoOperands, -1, -1, -1)
+
+	cg.patchJumps(failPatches, cg.nextInstructionAddr)
+	cg.emitMov(isa.MvImmReg, isa.RA, 0, -1)
+	cg.emitInstruction(isa.OpRet, isa.NoOperands, -1, -1, -1)
+	cg.debugAssembly = append(cg.debugAssembly, " # END OF FUNCTION alloc")
+}
@@ -0,0 +1,533 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
+	"github.com/awesoma31/csa-lab4/pkg/translator/isa"
+	"github.com/awesoma31/csa-lab4/pkg/translator/lexer"
+)
+
+// 64-bit arithmetic
+//
+// A long is kept in data memory as two little-endian words, the low word first.
+// A long expression is evaluated into the register pair R7:R8 (low:high), the
+// right operand of a binary operator is moved to RD:RT2 while the left one is
+// saved on the stack, as genEx does for ints. An int operand is sign-extended,
+// a uint one zero-extended.
+//
+// The ALU is 32-bit, the carry between the words is passed by ADC and SBC:
+//
+//	a + b:   ADD lo, a.lo, b.lo      a - b:   SUB lo, a.lo, b.lo
+//	         ADC hi, a.hi, b.hi               SBC hi, a.hi, b.hi   ; C is set when there is no borrow
+//
+// With Options.BranchCarry the carry is handled with branches instead, as
+// before ADC and SBC were added:
+//
+//	a + b:   ADD lo, a.lo, b.lo      a - b:   SUB lo, a.lo, b.lo
+//	         JCC nc                           JCS nb
+//	         ADD hi, hi, #1                   SUB hi, hi, #1
+//	     nc: ADD hi, a.hi, b.hi           nb: SUB hi, a.hi, b.hi
+//
+// `*` is a shift-and-add loop, `/` and `%` a restoring division of the absolute
+// values with the sign fixed afterwards. Dividing by zero executes DIV by
+// the zero register, which sets V and raises the division by zero trap like
+// int `/`, and gives 0 if there is no handler or the handler returns.
+// Comparisons compare the high words as signed and, when they are equal,
+// the low words as unsigned using the carry flag set by CMP (C = a < b).
+
+const (
+	longLo  = isa.R7  // low word of a long result and of the left operand
+	longHi  = isa.R8  // high word of a long result and of the left operand
+	rightLo = isa.RD  // low word of the right operand
+	rightHi = isa.RT2 // high word of the right operand
+)
+
+// isLong reports whether expr has the long type.
+func isLong(expr ast.Expr) bool {
+	return expr != nil && ast.KindOf(expr.StaticType()) == ast.TypeLong
+}
+
+// longScratch returns the address of a long in data memory used to pass
+// a value to and from the long port, it is allocated on first use.
+func (cg *CodeGenerator) longScratch() uint32 {
+	if cg.longScratchAddr == 0 {
+		cg.longScratchAddr = cg.addLongData(0)
+	}
+	return cg.longScratchAddr
+}
+
+// genLongEx generates a long (or widened int) expression, leaving its value in longLo:longHi.
+func (cg *CodeGenerator) genLongEx(expr ast.Expr) {
+	defer cg.enter(expr)()
+	switch e := expr.(type) {
+	case ast.LongNumberExpr:
+		cg.emitMov(isa.MvImmReg, longLo, isa.Register(uint32(e.Value)), -1)
+		cg.emitMov(isa.MvImmReg, longHi, isa.Register(uint32(e.Value>>32)), -1)
+	case ast.NumberExpr:
+		cg.emitMov(isa.MvImmReg, longLo, isa.Register(e.Value), -1)
+		cg.emitMov(isa.MvImmReg, longHi, isa.Register(e.Value>>31), -1)
+	case ast.SymbolExpr:
+		symbol, found := cg.lookupSymbol(e.Value)
+		if !found {
+			cg.addError(fmt.Sprintf("Undeclared variable in long expr: %s", e.Value))
+			return
+		}
+		cg.emitMov(isa.MvMemReg, longLo, isa.Register(symbol.AbsAddress), -1)
+		if !symbol.IsLong {
+			cg.genWiden(e)
+			return
+		}
+		cg.emitMov(isa.MvMemReg, longHi, isa.Register(symbol.AbsAddress+WordSizeBytes), -1)
+	case ast.ArrayIndexEx:
+		if !isLong(e) {
+			cg.genWidenedEx(e)
+			return
+		}
+		cg.genArrayAddress(e, isa.RAddr)
+		cg.genLoadLongElement()
+	case ast.ReadLongExpr:
+		cg.debugAssembly = append(cg.debugAssembly, "READ LONG EXPR")
+		scratch := cg.longScratch()
+		cg.emitMov(isa.MvImmReg, isa.RInData, isa.Register(scratch), -1)
+		cg.emitInstruction(isa.OpIn, isa.LongM, isa.PortL, -1, -1)
+		cg.emitMov(isa.MvMemReg, longLo, isa.Register(scratch), -1)
+		cg.emitMov(isa.MvMemReg, longHi, isa.Register(scratch+WordSizeBytes), -1)
+	case ast.BinaryExpr:
+		if !isLong(e) {
+			cg.genWidenedEx(e)
+			return
+		}
+		cg.genLongBinary(e.Left, e.Operator, e.Right)
+	case ast.PrefixExpr:
+		if e.Operator.Kind != lexer.MINUS || !isLong(e) {
+			cg.genWidenedEx(e)
+			return
+		}
+		cg.genLongEx(e.Right)
+		cg.genLongNegate(longLo, longHi)
+	case ast.CallExpr:
+		if e.Name != lexer.TokenKindString(lexer.ADDL) {
+			cg.genWidenedEx(e)
+			return
+		}
+		if len(e.Args) != 2 {
+			cg.addError(fmt.Sprintf("%s( , ) must have 2 arguments, got %d", e.Name, len(e.Args)))
+			return
+		}
+		cg.genLongBinary(e.Args[0], lexer.Token{Kind: lexer.PLUS, Value: "+"}, e.Args[1])
+	case ast.AssignmentExpr:
+		if !isLong(e) {
+			cg.genWidenedEx(e)
+			return
+		}
+		cg.genLongAssign(e)
+	case ast.TernaryExpr:
+		if !isLong(e) {
+			cg.genWidenedEx(e)
+			return
+		}
+		falsePatches := cg.genJumpIfFalse(e.Condition)
+		cg.genLongEx(e.Consequent)
+		endPatch := cg.emitJump(isa.OpJmp)
+		cg.patchJumps(falsePatches, cg.nextInstructionAddr)
+		cg.genLongEx(e.Alternate)
+		cg.PatchWord(endPatch, cg.nextInstructionAddr)
+	default:
+		cg.genWidenedEx(expr)
+	}
+}
+
+// genWidenedEx generates a word expression and extends it to a long.
+func (cg *CodeGenerator) genWidenedEx(expr ast.Expr) {
+	cg.genEx(expr, longLo)
+	cg.genWiden(expr)
+}
+
+// genSignExtend fills longHi with the sign bit of longLo.
+func (cg *CodeGenerator) genSignExtend() {
+	cg.emitMov(isa.MvImmReg, longHi, 31, -1)
+	cg.emitInstruction(isa.OpSar, isa.MathRRR, longHi, longLo, longHi)
+}
+
+// genLongBinary generates `left op right` for an arithmetic operator.
+func (cg *CodeGenerator) genLongBinary(left ast.Expr, op lexer.Token, right ast.Expr) {
+	cg.genLongEx(left)
+	cg.emitPushReg(longLo)
+	cg.emitPushReg(longHi)
+
+	cg.genLongEx(right)
+	cg.emitMov(isa.MvRegReg, rightLo, longLo, -1)
+	cg.emitMov(isa.MvRegReg, rightHi, longHi, -1)
+
+	cg.emitPopToReg(longHi)
+	cg.emitPopToReg(longLo)
+
+	switch op.Kind {
+	case lexer.PLUS:
+		cg.genLongAdd()
+	case lexer.MINUS:
+		cg.genLongSub()
+	case lexer.STAR:
+		cg.genLongMul()
+	case lexer.SLASH:
+		cg.genLongDiv(false)
+	case lexer.PERCENT:
+		cg.genLongDiv(true)
+	default:
+		cg.addError(fmt.Sprintf("operator %s is not supported for long", op.Value))
+	}
+}
+
+// emitAdc generates rd += rs + C, the high word of a long addition.
+func (cg *CodeGenerator) emitAdc(rd, rs isa.Register) {
+	if !cg.opts.BranchCarry {
+		cg.emitInstruction(isa.OpAdc, isa.MathRRR, rd, rd, rs)
+		return
+	}
+	noCarry := cg.emitJump(isa.OpJcc)
+	cg.emitInstruction(isa.OpAdd, isa.MathRIR, rd, rd, -1)
+	cg.emitImmediate(1)
+	cg.PatchWord(noCarry, cg.nextInstructionAddr)
+	if rs != isa.ZERO {
+		cg.emitInstruction(isa.OpAdd, isa.MathRRR, rd, rd, rs)
+	}
+}
+
+// emitSbc generates rd -= rs + !C, the high word of a long subtraction.
+func (cg *CodeGenerator) emitSbc(rd, rs isa.Register) {
+	if !cg.opts.BranchCarry {
+		cg.emitInstruction(isa.OpSbc, isa.MathRRR, rd, rd, rs)
+		return
+	}
+	noBorrow := cg.emitJump(isa.OpJcs)
+	cg.emitInstruction(isa.OpSub, isa.MathRIR, rd, rd, -1)
+	cg.emitImmediate(1)
+	cg.PatchWord(noBorrow, cg.nextInstructionAddr)
+	cg.emitInstruction(isa.OpSub, isa.MathRRR, rd, rd, rs)
+}
+
+// genLongAdd generates longLo:longHi += rightLo:rightHi.
+func (cg *CodeGenerator) genLongAdd() {
+	cg.emitInstruction(isa.OpAdd, isa.MathRRR, longLo, longLo, rightLo)
+	cg.emitAdc(longHi, rightHi)
+}
+
+// genLongSub generates longLo:longHi -= rightLo:rightHi.
+func (cg *CodeGenerator) genLongSub() {
+	cg.emitInstruction(isa.OpSub, isa.MathRRR, longLo, longLo, rightLo)
+	cg.emitSbc(longHi, rightHi)
+}
+
+// genLongNegate negates the long in lo:hi: ~x + 1.
+func (cg *CodeGenerator) genLongNegate(lo, hi isa.Register) {
+	cg.emitInstruction(isa.OpNot, isa.RegReg, lo, lo, -1)
+	cg.emitInstruction(isa.OpNot, isa.RegReg, hi, hi, -1)
+	cg.emitInstruction(isa.OpAdd, isa.MathRIR, lo, lo, -1)
+	cg.emitImmediate(1)
+	cg.emitAdc(hi, isa.ZERO)
+}
+
+// genShiftLeft1 shifts lo:hi left by one bit, the bit shifted out of hi is lost.
+// RC must hold 1, RM2 is clobbered.
+func (cg *CodeGenerator) genShiftLeft1(lo, hi isa.Register) {
+	if !cg.opts.BranchCarry {
+		// x + x, the carry out of lo is shifted into hi
+		cg.emitInstruction(isa.OpAdd, isa.MathRRR, lo, lo, lo)
+		cg.emitInstruction(isa.OpAdc, isa.MathRRR, hi, hi, hi)
+		return
+	}
+	cg.emitInstruction(isa.OpShl, isa.MathRRR, hi, hi, isa.RC)
+	cg.emitMov(isa.MvImmReg, isa.RM2, 31, -1)
+	cg.emitInstruction(isa.OpShr, isa.MathRRR, isa.RM2, lo, isa.RM2)
+	cg.emitInstruction(isa.OpOr, isa.RegReg, hi, hi, isa.RM2)
+	cg.emitInstruction(isa.OpShl, isa.MathRRR, lo, lo, isa.RC)
+}
+
+// genLongMul generates longLo:longHi *= rightLo:rightHi, the low 64 bits of the product.
+//
+//	p = 0
+//	while b != 0 {
+//		if b & 1 { p += a }
+//		a <<= 1; b >>>= 1
+//	}
+func (cg *CodeGenerator) genLongMul() {
+	cg.debugAssembly = append(cg.debugAssembly, "LONG MUL")
+	prodLo, prodHi := isa.RA, isa.RM1
+	cg.emitMov(isa.MvImmReg, prodLo, 0, -1)
+	cg.emitMov(isa.MvImmReg, prodHi, 0, -1)
+	cg.emitMov(isa.MvImmReg, isa.RC, 1, -1)
+
+	loopAddr := cg.nextInstructionAddr
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, rightLo, isa.ZERO)
+	bodyPatch := cg.emitJump(isa.OpJne)
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, rightHi, isa.ZERO)
+	endPatch := cg.emitJump(isa.OpJe)
+	cg.PatchWord(bodyPatch, cg.nextInstructionAddr)
+
+	cg.emitInstruction(isa.OpAnd, isa.RegReg, isa.RM2, rightLo, isa.RC)
+	skipAdd := cg.emitJump(isa.OpJe)
+	cg.emitInstruction(isa.OpAdd, isa.MathRRR, prodLo, prodLo, longLo)
+	cg.emitAdc(prodHi, longHi)
+	cg.PatchWord(skipAdd, cg.nextInstructionAddr)
+
+	cg.genShiftLeft1(longLo, longHi)
+	// b >>>= 1
+	cg.emitInstruction(isa.OpShr, isa.MathRRR, rightLo, rightLo, isa.RC)
+	cg.emitMov(isa.MvImmReg, isa.RM2, 31, -1)
+	cg.emitInstruction(isa.OpShl, isa.MathRRR, isa.RM2, rightHi, isa.RM2)
+	cg.emitInstruction(isa.OpOr, isa.RegReg, rightLo, rightLo, isa.RM2)
+	cg.emitInstruction(isa.OpShr, isa.MathRRR, rightHi, rightHi, isa.RC)
+	cg.emitInstruction(isa.OpJmp, isa.JAbsAddr, -1, -1, -1)
+	cg.emitImmediate(loopAddr)
+
+	cg.PatchWord(endPatch, cg.nextInstructionAddr)
+	cg.emitMov(isa.MvRegReg, longLo, prodLo, -1)
+	cg.emitMov(isa.MvRegReg, longHi, prodHi, -1)
+}
+
+// genLongDiv generates longLo:longHi /= rightLo:rightHi, or %= if rem is set.
+// The quotient is truncated toward zero and the remainder takes the sign of the dividend.
+//
+//	r = 0
+//	repeat 64 times {
+//		r:a <<= 1
+//		if r >= b (unsigned) { r -= b; a |= 1 }
+//	}
+//
+// leaves the quotient in a and the remainder in r.
+func (cg *CodeGenerator) genLongDiv(rem bool) {
+	cg.debugAssembly = append(cg.debugAssembly, "LONG DIV")
+	remLo, remHi := isa.RA, isa.RM1
+	sign, counter := isa.RAddr, isa.R6
+
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, rightLo, isa.ZERO)
+	nonZero := []uint32{cg.emitJump(isa.OpJne)}
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, rightHi, isa.ZERO)
+	nonZero = append(nonZero, cg.emitJump(isa.OpJne))
+	cg.emitInstruction(isa.OpDiv, isa.MathRRR, longLo, longLo, isa.ZERO)
+	cg.emitMov(isa.MvImmReg, longLo, 0, -1)
+	cg.emitMov(isa.MvImmReg, longHi, 0, -1)
+	endPatch := cg.emitJump(isa.OpJmp)
+	cg.patchJumps(nonZero, cg.nextInstructionAddr)
+
+	// the sign of the result is in the sign bit of `sign`
+	if rem {
+		cg.emitMov(isa.MvRegReg, sign, longHi, -1)
+	} else {
+		cg.emitInstruction(isa.OpXor, isa.RegReg, sign, longHi, rightHi)
+	}
+	for _, pair := range [][2]isa.Register{{longLo, longHi}, {rightLo, rightHi}} {
+		cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, pair[1], isa.ZERO)
+		positive := cg.emitJump(isa.OpJge)
+		cg.genLongNegate(pair[0], pair[1])
+		cg.PatchWord(positive, cg.nextInstructionAddr)
+	}
+
+	cg.emitMov(isa.MvImmReg, remLo, 0, -1)
+	cg.emitMov(isa.MvImmReg, remHi, 0, -1)
+	cg.emitMov(isa.MvImmReg, isa.RC, 1, -1)
+	cg.emitMov(isa.MvImmReg, counter, 64, -1)
+
+	loopAddr := cg.nextInstructionAddr
+	if !cg.opts.BranchCarry {
+		cg.genLongDivStep(remLo, remHi)
+	} else {
+		cg.genLongDivStepBranches(remLo, remHi)
+	}
+
+	cg.emitInstruction(isa.OpSub, isa.MathRIR, counter, counter, -1)
+	cg.emitImmediate(1)
+	cg.emitInstruction(isa.OpJne, isa.JAbsAddr, -1, -1, -1)
+	cg.emitImmediate(loopAddr)
+
+	if rem {
+		cg.emitMov(isa.MvRegReg, longLo, remLo, -1)
+		cg.emitMov(isa.MvRegReg, longHi, remHi, -1)
+	}
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, sign, isa.ZERO)
+	positive := cg.emitJump(isa.OpJge)
+	cg.genLongNegate(longLo, longHi)
+	cg.PatchWord(positive, cg.nextInstructionAddr)
+	cg.PatchWord(endPatch, cg.nextInstructionAddr)
+}
+
+// genLongDivStep generates one step of the division with ADC and SBC.
+// The 128-bit r:a is shifted by adding it to itself, then r - b is computed
+// into RM2:RT and kept if it does not borrow.
+func (cg *CodeGenerator) genLongDivStep(remLo, remHi isa.Register) {
+	cg.emitInstruction(isa.OpAdd, isa.MathRRR, longLo, longLo, longLo)
+	cg.emitInstruction(isa.OpAdc, isa.MathRRR, longHi, longHi, longHi)
+	cg.emitInstruction(isa.OpAdc, isa.MathRRR, remLo, remLo, remLo)
+	cg.emitInstruction(isa.OpAdc, isa.MathRRR, remHi, remHi, remHi)
+
+	cg.emitInstruction(isa.OpSub, isa.MathRRR, isa.RM2, remLo, rightLo)
+	cg.emitInstruction(isa.OpSbc, isa.MathRRR, isa.RT, remHi, rightHi)
+	less := cg.emitJump(isa.OpJcc)
+	cg.emitMov(isa.MvRegReg, remLo, isa.RM2, -1)
+	cg.emitMov(isa.MvRegReg, remHi, isa.RT, -1)
+	cg.emitInstruction(isa.OpOr, isa.RegReg, longLo, longLo, isa.RC)
+	cg.PatchWord(less, cg.nextInstructionAddr)
+}
+
+// genLongDivStepBranches generates one step of the division for Options.BranchCarry.
+func (cg *CodeGenerator) genLongDivStepBranches(remLo, remHi isa.Register) {
+	cg.genShiftLeft1(remLo, remHi)
+	cg.emitMov(isa.MvImmReg, isa.RM2, 31, -1)
+	cg.emitInstruction(isa.OpShr, isa.MathRRR, isa.RM2, longHi, isa.RM2)
+	cg.emitInstruction(isa.OpOr, isa.RegReg, remLo, remLo, isa.RM2)
+	cg.genShiftLeft1(longLo, longHi)
+
+	// r >= b: the high words decide unless they are equal
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, remHi, rightHi)
+	less := []uint32{cg.emitJump(isa.OpJcs)}
+	greater := cg.emitJump(isa.OpJne)
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, remLo, rightLo)
+	less = append(less, cg.emitJump(isa.OpJcs))
+	cg.PatchWord(greater, cg.nextInstructionAddr)
+
+	cg.emitInstruction(isa.OpSub, isa.MathRRR, remLo, remLo, rightLo)
+	cg.emitSbc(remHi, rightHi)
+	cg.emitInstruction(isa.OpOr, isa.RegReg, longLo, longLo, isa.RC)
+	cg.patchJumps(less, cg.nextInstructionAddr)
+}
+
+// longOps maps compound assignment operators to the arithmetic applied to a long target.
+var longOps = map[lexer.TokenKind]lexer.Token{
+	lexer.PlusEquals:    {Kind: lexer.PLUS, Value: "+"},
+	lexer.MinusEquals:   {Kind: lexer.MINUS, Value: "-"},
+	lexer.StarEquals:    {Kind: lexer.STAR, Value: "*"},
+	lexer.SlashEquals:   {Kind: lexer.SLASH, Value: "/"},
+	lexer.PercentEquals: {Kind: lexer.PERCENT, Value: "%"},
+	lexer.PlusPlus:      {Kind: lexer.PLUS, Value: "+"},
+	lexer.MinusMinus:    {Kind: lexer.MINUS, Value: "-"},
+}
+
+// genLongAssign generates an assignment to a long variable, the value is left in longLo:longHi.
+func (cg *CodeGenerator) genLongAssign(e ast.AssignmentExpr) {
+	if element, ok := e.Assigne.(ast.ArrayIndexEx); ok {
+		cg.genLongAssignArray(e, element)
+		return
+	}
+	target, ok := e.Assigne.(ast.SymbolExpr)
+	if !ok {
+		cg.addError(fmt.Sprintf("Unsupported long assignment target type: %T", e.Assigne))
+		return
+	}
+	symbol, found := cg.lookupSymbol(target.Value)
+	if !found {
+		cg.addError(fmt.Sprintf("Undeclared variable '%s' used in assignment.", target.Value))
+		return
+	}
+	if op, compound := longOps[e.Operator.Kind]; compound {
+		cg.genLongBinary(target, op, e.AssignedValue)
+	} else {
+		cg.genLongEx(e.AssignedValue)
+	}
+	cg.genStoreLong(symbol.AbsAddress)
+}
+
+// genStoreLong stores longLo:longHi to the long at addr.
+func (cg *CodeGenerator) genStoreLong(addr uint32) {
+	cg.emitMov(isa.MvRegMem, isa.Register(addr), longLo, -1)
+	cg.emitMov(isa.MvRegMem, isa.Register(addr+WordSizeBytes), longHi, -1)
+}
+
+// genLongVarDecl declares a long variable initialized at runtime with the value of s.
+func (cg *CodeGenerator) genLongVarDecl(s ast.VarDeclarationStmt) {
+	symbol := SymbolEntry{
+		Name:        s.Identifier,
+		Type:        ast.LongType,
+		MemoryArea:  "data",
+		AbsAddress:  cg.addLongData(0),
+		SizeInBytes: WordSizeBytes * 2,
+		IsLong:      true,
+	}
+	cg.genLongEx(s.AssignedValue)
+	cg.addSymbolToScope(symbol)
+	cg.genStoreLong(symbol.AbsAddress)
+}
+
+// genPrintLong prints a long expression to the long port.
+func (cg *CodeGenerator) genPrintLong(expr ast.Expr) {
+	var addr uint32
+	if symbol, found := cg.lookupLongSymbol(expr); found {
+		addr = symbol.AbsAddress
+	} else {
+		addr = cg.longScratch()
+		cg.genLongEx(expr)
+		cg.genStoreLong(addr)
+	}
+	cg.emitMov(isa.MvImmReg, isa.ROutAddr, isa.Register(addr), -1)
+	cg.emitInstruction(isa.OpOut, isa.LongM, isa.PortL, -1, -1)
+}
+
+// lookupLongSymbol returns the symbol of expr if it is a long variable.
+func (cg *CodeGenerator) lookupLongSymbol(expr ast.Expr) (SymbolEntry, bool) {
+	if e, ok := expr.(ast.SymbolExpr); ok {
+		if symbol, found := cg.lookupSymbol(e.Value); found && symbol.IsLong {
+			return symbol, true
+		}
+	}
+	return SymbolEntry{}, false
+}
+
+// longRelations maps a relational operator to the one that holds when it does not.
+var longRelations = map[lexer.TokenKind]lexer.TokenKind{
+	lexer.EQUALS:        lexer.NotEquals,
+	lexer.NotEquals:     lexer.EQUALS,
+	lexer.LESS:          lexer.GreaterEquals,
+	lexer.GreaterEquals: lexer.LESS,
+	lexer.GREATER:       lexer.LessEquals,
+	lexer.LessEquals:    lexer.GREATER,
+}
+
+// genLongJump generates a comparison with a long operand and jumps away when
+// `left op right` holds, or when it does not if onTrue is false.
+// Returns the jump operands to patch with the target.
+func (cg *CodeGenerator) genLongJump(e ast.BinaryExpr, onTrue bool) []uint32 {
+	op := e.Operator.Kind
+	if !onTrue {
+		op = longRelations[op]
+	}
+	cg.genLongEx(e.Left)
+	cg.emitPushReg(longLo)
+	cg.emitPushReg(longHi)
+	cg.genLongEx(e.Right)
+	cg.emitMov(isa.MvRegReg, rightLo, longLo, -1)
+	cg.emitMov(isa.MvRegReg, rightHi, longHi, -1)
+	cg.emitPopToReg(longHi)
+	cg.emitPopToReg(longLo)
+
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, longHi, rightHi)
+	var patches []uint32
+	switch op {
+	case lexer.EQUALS:
+		differ := cg.emitJump(isa.OpJne)
+		cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, longLo, rightLo)
+		patches = append(patches, cg.emitJump(isa.OpJe))
+		cg.PatchWord(differ, cg.nextInstructionAddr)
+	case lexer.NotEquals:
+		patches = append(patches, cg.emitJump(isa.OpJne))
+		cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, longLo, rightLo)
+		patches = append(patches, cg.emitJump(isa.OpJne))
+	default:
+		// the high words decide unless they are equal, then the low words compare unsigned
+		highJump, lowJump := isa.OpJl, isa.OpJcs
+		lowLeft, lowRight := longLo, rightLo
+		switch op {
+		case lexer.GREATER:
+			highJump, lowLeft, lowRight = isa.OpJg, rightLo, longLo
+		case lexer.GreaterEquals:
+			highJump, lowJump = isa.OpJg, isa.OpJcc
+		case lexer.LessEquals:
+			lowJump, lowLeft, lowRight = isa.OpJcc, rightLo, longLo
+		}
+		patches = append(patches, cg.emitJump(highJump))
+		differ := cg.emitJump(isa.OpJne)
+		cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, lowLeft, lowRight)
+		patches = append(patches, cg.emitJump(lowJump))
+		cg.PatchWord(differ, cg.nextInstructionAddr)
+	}
+	return patches
+}
@@ -0,0 +1,194 @@
+package codegen
+
+import (
+	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
+	"github.com/awesoma31/csa-lab4/pkg/translator/isa"
+)
+
+// pushLoop opens a loop context for break/continue inside its body.
+func (cg *CodeGenerator) pushLoop() *loopContext {
+	loop := &loopContext{}
+	cg.loops = append(cg.loops, loop)
+	return loop
+}
+
+func (cg *CodeGenerator) popLoop() {
+	cg.loops = cg.loops[:len(cg.loops)-1]
+}
+
+func (cg *CodeGenerator) genBreakStmt() {
+	if len(cg.loops) == 0 {
+		cg.addError("break statement outside of loop")
+		return
+	}
+	cg.debugAssembly = append(cg.debugAssembly, "BREAK STMT")
+	loop := cg.loops[len(cg.loops)-1]
+	loop.breakPatches = append(loop.breakPatches, cg.emitJump(isa.OpJmp))
+}
+
+func (cg *CodeGenerator) genContinueStmt() {
+	if len(cg.loops) == 0 {
+		cg.addError("continue statement outside of loop")
+		return
+	}
+	cg.debugAssembly = append(cg.debugAssembly, "CONTINUE STMT")
+	loop := cg.loops[len(cg.loops)-1]
+	loop.continuePatches = append(loop.continuePatches, cg.emitJump(isa.OpJmp))
+}
+
+// genForStmt generates a C-style loop, `continue` jumps to the post expression.
+func (cg *CodeGenerator) genForStmt(s ast.ForStmt) {
+	cg.debugAssembly = append(cg.debugAssembly, "FOR STMT INIT:")
+	cg.pushScope("for")
+	if s.Init != nil {
+		cg.generateStmt(s.Init)
+	}
+
+	cg.debugAssembly = append(cg.debugAssembly, "FOR STMT CONDITION:")
+	conditionAddr := cg.nextInstructionAddr
+	falsePatches := cg.genJumpIfFalse(s.Condition)
+
+	cg.debugAssembly = append(cg.debugAssembly, "FOR STMT BODY:")
+	loop := cg.pushLoop()
+	cg.generateBody(s.Body, "for body")
+	cg.popLoop()
+
+	cg.debugAssembly = append(cg.debugAssembly, "FOR STMT POST:")
+	postAddr := cg.nextInstructionAddr
+	if s.Post != nil {
+		cg.genEx(s.Post, isa.RA)
+	}
+	cg.emitInstruction(isa.OpJmp, isa.JAbsAddr, -1, -1, -1)
+	cg.emitImmediate(conditionAddr)
+
+	cg.patchJumps(falsePatches, cg.nextInstructionAddr)
+	cg.patchJumps(loop.breakPatches, cg.nextInstructionAddr)
+	cg.patchJumps(loop.continuePatches, postAddr)
+	cg.popScope()
+	cg.debugAssembly = append(cg.debugAssembly, " # END OF FOR STMT")
+}
+
+// genForeachStmt generates `for x in a..b {}` and `for x in arr {}`.
+// A hidden cursor walks from the start to the end evaluated once before the loop,
+// the loop variable gets a copy of the current value, so assigning to it does not affect iteration.
+func (cg *CodeGenerator) genForeachStmt(s ast.ForeachStmt) {
+	cg.debugAssembly = append(cg.debugAssembly, "FOREACH STMT BOUNDS:")
+	cg.pushScope("for")
+
+	size := elementSize(s.Iterable.StaticType())
+	value := SymbolEntry{
+		Name:        s.Value,
+		Type:        ast.IntType,
+		MemoryArea:  "data",
+		SizeInBytes: WordSizeBytes,
+	}
+	if size == WordSizeBytes*2 {
+		value.Type, value.SizeInBytes, value.IsLong = ast.LongType, WordSizeBytes*2, true
+		value.AbsAddress = cg.addLongData(0)
+	} else {
+		value.AbsAddress = cg.addNumberData(0)
+	}
+	valueAddr := value.AbsAddress
+	cg.addSymbolToScope(value)
+	cursor := cg.addHiddenWord()
+	end := cg.addHiddenWord()
+
+	rangeExpr, isRange := s.Iterable.(ast.RangeExpr)
+	if isRange {
+		cg.genEx(rangeExpr.Lower, isa.RA)
+		cg.emitMov(isa.MvRegMem, isa.Register(cursor), isa.RA, -1)
+		cg.genEx(rangeExpr.Upper, isa.RA)
+		cg.emitMov(isa.MvRegMem, isa.Register(end), isa.RA, -1)
+	} else {
+		cg.genIterableBounds(s.Iterable, cursor, end)
+	}
+
+	cg.debugAssembly = append(cg.debugAssembly, "FOREACH STMT CONDITION:")
+	conditionAddr := cg.nextInstructionAddr
+	cg.emitMov(isa.MvMemReg, isa.RM1, isa.Register(cursor), -1)
+	cg.emitMov(isa.MvMemReg, isa.RM2, isa.Register(end), -1)
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, isa.RM1, isa.RM2)
+	exitPatch := cg.emitJump(isa.OpJge)
+
+	switch {
+	case isRange:
+		cg.emitMov(isa.MvMemReg, isa.RA, isa.Register(cursor), -1)
+		cg.emitMov(isa.MvRegMem, isa.Register(valueAddr), isa.RA, -1)
+	case value.IsLong:
+		cg.emitMov(isa.MvMemReg, isa.RAddr, isa.Register(cursor), -1)
+		cg.genLoadLongElement()
+		cg.genStoreLong(valueAddr)
+	default:
+		cg.emitMov(isa.MvMemReg, isa.RAddr, isa.Register(cursor), -1)
+		cg.genLoadElement(size, isa.RA, isa.RAddr)
+		cg.emitMov(isa.MvRegMem, isa.Register(valueAddr), isa.RA, -1)
+	}
+
+	cg.debugAssembly = append(cg.debugAssembly, "FOREACH STMT BODY:")
+	loop := cg.pushLoop()
+	cg.generateScopedBlock(ast.BlockStmt{Body: s.Body}, "for body")
+	cg.popLoop()
+
+	cg.debugAssembly = append(cg.debugAssembly, "FOREACH STMT STEP:")
+	stepAddr := cg.nextInstructionAddr
+	cg.emitMov(isa.MvMemReg, isa.RA, isa.Register(cursor), -1)
+	cg.emitInstruction(isa.OpAdd, isa.MathRIR, isa.RA, isa.RA, -1)
+	if isRange {
+		cg.emitImmediate(1)
+	} else {
+		cg.emitImmediate(size)
+	}
+	cg.emitMov(isa.MvRegMem, isa.Register(cursor), isa.RA, -1)
+	cg.emitInstruction(isa.OpJmp, isa.JAbsAddr, -1, -1, -1)
+	cg.emitImmediate(conditionAddr)
+
+	cg.PatchWord(exitPatch, cg.nextInstructionAddr)
+	cg.patchJumps(loop.breakPatches, cg.nextInstructionAddr)
+	cg.patchJumps(loop.continuePatches, stepAddr)
+	cg.popScope()
+	cg.debugAssembly = append(cg.debugAssembly, " # END OF FOREACH STMT")
+}
+
+// genIterableBounds stores the address of the first element of a list or string to cursor
+// and the address past its last element to end.
+// A list keeps its byte size in the header word right before the buffer,
+// a Pascal string keeps its length in the first byte.
+func (cg *CodeGenerator) genIterableBounds(iterable ast.Expr, cursor, end uint32) {
+	isStr := ast.KindOf(iterable.StaticType()) == ast.TypeString
+	switch it := iterable.(type) {
+	case ast.StringExpr:
+		isStr = true
+		cg.genStringEx(it, isa.RA)
+	case ast.SymbolExpr:
+		if symbol, found := cg.lookupSymbol(it.Value); found && symbol.IsStr {
+			isStr = true
+		}
+		cg.genEx(it, isa.RA)
+	default:
+		cg.genEx(it, isa.RA)
+	}
+
+	cg.emitMov(isa.MvRegReg, isa.RAddr, isa.RA, -1)
+	if isStr {
+		cg.emitMov(isa.MvByteRegIndToReg, isa.RM1, isa.RAddr, -1)
+		cg.emitInstruction(isa.OpAdd, isa.MathRIR, isa.RA, isa.RA, -1)
+		cg.emitImmediate(1)
+	} else {
+		cg.emitInstruction(isa.OpSub, isa.MathRIR, isa.RAddr, isa.RAddr, -1)
+		cg.emitImmediate(WordSizeBytes)
+		cg.emitMov(isa.MvRegIndToReg, isa.RM1, isa.RAddr, -1)
+	}
+	cg.emitMov(isa.MvRegMem, isa.Register(cursor), isa.RA, -1)
+	cg.emitInstruction(isa.OpAdd, isa.MathRRR, isa.RA, isa.RA, isa.RM1)
+	cg.emitMov(isa.MvRegMem, isa.Register(end), isa.RA, -1)
+}
+
+// addHiddenWord allocates a data word invisible to the program, e.g. a loop cursor.
+// Inside a function it becomes part of the frame like any local.
+func (cg *CodeGenerator) addHiddenWord() uint32 {
+	addr := cg.addNumberData(0)
+	if cg.currentFn != nil {
+		cg.currentFn.addFrameSlots(addr, WordSizeBytes)
+	}
+	return addr
+}
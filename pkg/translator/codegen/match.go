@@ -0,0 +1,137 @@
+package codegen
+
+import (
+	"sort"
+
+	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
+	"github.com/awesoma31/csa-lab4/pkg/translator/isa"
+)
+
+// Match statements
+//
+// The subject is evaluated once into RA, then one of two dispatches is used.
+//
+// Dense values (at least minJumpTableCases, span at most maxJumpTableSpan
+// times the number of values) go through a jump table in instruction memory:
+//
+//	SUB RA, RA, #min
+//	MOV RM1, #span
+//	CMP RA, RM1
+//	JAE default          unsigned, so values below min are out of range too
+//	ADD RA, RA, RA       a table entry is 2 words
+//	ADD RA, RA, #table
+//	JMP RA
+//	table: JMP arm(min), JMP arm(min+1), ...   holes jump to default
+//
+// The dispatch costs the same number of ticks for any value. Sparse values
+// are compared one by one:
+//
+//	MOV RM1, #v; CMP RA, RM1; JE arm      for every value
+//	JMP default
+//
+// Every arm body ends with a jump past the match, the default is the last one.
+
+const (
+	minJumpTableCases = 4
+	maxJumpTableSpan  = 3
+)
+
+// matchCase is an arm value with the arm it selects.
+type matchCase struct {
+	value int32
+	arm   int
+}
+
+func (cg *CodeGenerator) genMatchStmt(s ast.MatchStmt) {
+	cg.debugAssembly = append(cg.debugAssembly, "MATCH STMT SUBJECT:")
+	cg.genEx(s.Subject, isa.RA)
+
+	var cases []matchCase
+	for i, arm := range s.Arms {
+		for _, v := range arm.Values {
+			cases = append(cases, matchCase{value: v, arm: i})
+		}
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].value < cases[j].value })
+
+	// armPatches[i] collects jump operands to patch with the start of arm i
+	armPatches := make([][]uint32, len(s.Arms))
+	var defaultPatches []uint32
+	if isDense(cases) {
+		defaultPatches = cg.genJumpTable(cases, armPatches)
+	} else {
+		defaultPatches = cg.genCompareChain(cases, armPatches)
+	}
+
+	var endPatches []uint32
+	for i, arm := range s.Arms {
+		cg.debugAssembly = append(cg.debugAssembly, "MATCH ARM:")
+		cg.patchJumps(armPatches[i], cg.nextInstructionAddr)
+		cg.generateScopedBlock(arm.Body, "match arm")
+		if i < len(s.Arms)-1 || s.Default != nil {
+			endPatches = append(endPatches, cg.emitJump(isa.OpJmp))
+		}
+	}
+
+	cg.patchJumps(defaultPatches, cg.nextInstructionAddr)
+	if s.Default != nil {
+		cg.debugAssembly = append(cg.debugAssembly, "MATCH DEFAULT:")
+		cg.generateBody(s.Default, "match default")
+	}
+	cg.patchJumps(endPatches, cg.nextInstructionAddr)
+	cg.debugAssembly = append(cg.debugAssembly, " # END OF MATCH STMT")
+}
+
+// isDense reports whether sorted cases are worth a jump table.
+func isDense(cases []matchCase) bool {
+	if len(cases) < minJumpTableCases {
+		return false
+	}
+	span := int64(cases[len(cases)-1].value) - int64(cases[0].value) + 1
+	return span <= int64(maxJumpTableSpan*len(cases))
+}
+
+// genJumpTable dispatches RA through a jump table, returns the jumps to the default arm.
+func (cg *CodeGenerator) genJumpTable(cases []matchCase, armPatches [][]uint32) []uint32 {
+	cg.debugAssembly = append(cg.debugAssembly, "MATCH JUMP TABLE DISPATCH:")
+	low := cases[0].value
+	span := uint32(int64(cases[len(cases)-1].value) - int64(low) + 1)
+
+	if low != 0 {
+		cg.emitInstruction(isa.OpSub, isa.MathRIR, isa.RA, isa.RA, -1)
+		cg.emitImmediate(uint32(low))
+	}
+	cg.emitMov(isa.MvImmReg, isa.RM1, isa.Register(span), -1)
+	cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, isa.RA, isa.RM1)
+	defaultPatches := []uint32{cg.emitJump(isa.OpJae)}
+
+	cg.emitInstruction(isa.OpAdd, isa.MathRRR, isa.RA, isa.RA, isa.RA)
+	cg.emitInstruction(isa.OpAdd, isa.MathRIR, isa.RA, isa.RA, -1)
+	tableOperand := cg.ReserveWord()
+	cg.emitInstruction(isa.OpJmp, isa.JReg, -1, isa.RA, -1)
+
+	cg.debugAssembly = append(cg.debugAssembly, "MATCH JUMP TABLE:")
+	cg.PatchWord(tableOperand, cg.nextInstructionAddr)
+	next := 0
+	for v := int64(low); v < int64(low)+int64(span); v++ {
+		operand := cg.emitJump(isa.OpJmp)
+		if int64(cases[next].value) == v {
+			armPatches[cases[next].arm] = append(armPatches[cases[next].arm], operand)
+			next++
+			continue
+		}
+		defaultPatches = append(defaultPatches, operand)
+	}
+	return defaultPatches
+}
+
+// genCompareChain compares RA with every case value, returns the jumps to the default arm.
+func (cg *CodeGenerator) genCompareChain(cases []matchCase, armPatches [][]uint32) []uint32 {
+	cg.debugAssembly = append(cg.debugAssembly, "MATCH COMPARE CHAIN:")
+	for _, mc := range cases {
+		cg.emitMov(isa.MvImmReg, isa.RM1, isa.Register(mc.value), -1)
+		cg.emitInstruction(isa.OpCmp, isa.RegReg, -1, isa.RA, isa.RM1)
+		armPatches[mc.arm] = append(armPatches[mc.arm], cg.emitJump(isa.OpJe))
+	}
+	return []uint32{cg.emitJump(isa.OpJmp)}
+}
@@ -0,0 +1,66 @@
+package codegen
+
+import (
+	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
+	"github.com/awesoma31/csa-lab4/pkg/translator/isa"
+	"github.com/awesoma31/csa-lab4/pkg/translator/lexer"
+)
+
+// Unsigned words
+//
+// A uint is stored like an int, only the operations that depend on the sign differ:
+//
+//	a < b, a >= b, ...   CMP sets C = a < b (unsigned), so JB/JAE/JA/JBE are used
+//	                     instead of JL/JGE/JG/JLE when either operand is a uint
+//	a / b, a % b         DIV and REM are signed, both operands are zero-extended
+//	                     to longs and divided by the long division routine
+//	a >> n               SHR instead of SAR
+//	widening to long     the high word is 0, print goes through the long port for that
+
+// unsignedJumps maps a relational operator to the jump taken when it holds for uint operands.
+var unsignedJumps = map[lexer.TokenKind]uint32{
+	lexer.EQUALS:        isa.OpJe,
+	lexer.NotEquals:     isa.OpJne,
+	lexer.GREATER:       isa.OpJa,
+	lexer.LESS:          isa.OpJb,
+	lexer.GreaterEquals: isa.OpJae,
+	lexer.LessEquals:    isa.OpJbe,
+}
+
+// isUnsigned reports whether expr has the uint type.
+func isUnsigned(expr ast.Expr) bool {
+	return expr != nil && ast.KindOf(expr.StaticType()) == ast.TypeUint
+}
+
+// relationalJump returns the jump taken when the comparison e holds.
+func relationalJump(e ast.BinaryExpr) (uint32, bool) {
+	if isUnsigned(e.Left) || isUnsigned(e.Right) {
+		jmp, ok := unsignedJumps[e.Operator.Kind]
+		return jmp, ok
+	}
+	jmp, ok := relationalJumps[e.Operator.Kind]
+	return jmp, ok
+}
+
+// isUnsignedDiv reports whether e is a uint division, which is generated as a long one.
+func isUnsignedDiv(e ast.BinaryExpr) bool {
+	return isUnsigned(e) && (e.Operator.Kind == lexer.SLASH || e.Operator.Kind == lexer.PERCENT)
+}
+
+// genUnsignedDiv generates a uint `/` or `%`, leaving the result in rd.
+// Every general purpose register except SP may be clobbered.
+func (cg *CodeGenerator) genUnsignedDiv(e ast.BinaryExpr, rd isa.Register) {
+	cg.genLongBinary(e.Left, e.Operator, e.Right)
+	if rd != -1 && rd != longLo {
+		cg.emitMov(isa.MvRegReg, rd, longLo, -1)
+	}
+}
+
+// genWiden fills longHi from longLo, which holds the value of the word expression expr.
+func (cg *CodeGenerator) genWiden(expr ast.Expr) {
+	if isUnsigned(expr) {
+		cg.emitMov(isa.MvImmReg, longHi, 0, -1)
+		return
+	}
+	cg.genSignExtend()
+}
@@ -0,0 +1,85 @@
+// Package diag formats translator diagnostics with their source location.
+package diag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesoma31/csa-lab4/pkg/translator/lexer"
+)
+
+// Error is a diagnostic attached to a position in the source.
+type Error struct {
+	Pos lexer.Pos
+	Len int // number of characters to underline, at least one caret is printed
+	Msg string
+}
+
+// Source is a named program text used to render excerpts.
+type Source struct {
+	File string
+	Text string
+}
+
+// Format renders the error as `file:line:col: msg` followed by the source line and a caret:
+//
+//	prog.lang:3:10: Expected token semi_colon but got let
+//	  3 | let a = 5
+//	    |          ^
+//
+// Without a known position only the message is returned.
+func (src Source) Format(e Error) string {
+	if !e.Pos.IsValid() {
+		if src.File == "" {
+			return e.Msg
+		}
+		return fmt.Sprintf("%s: %s", src.File, e.Msg)
+	}
+
+	var b strings.Builder
+	if src.File != "" {
+		fmt.Fprintf(&b, "%s:", src.File)
+	}
+	fmt.Fprintf(&b, "%s: %s", e.Pos, e.Msg)
+
+	line, ok := src.line(e.Pos.Line)
+	if !ok {
+		return b.String()
+	}
+	gutter := fmt.Sprintf("%d", e.Pos.Line)
+	pad := strings.Repeat(" ", len(gutter))
+	fmt.Fprintf(&b, "\n  %s | %s\n  %s | %s%s", gutter, line, pad, caretIndent(line, e.Pos.Col), strings.Repeat("^", max(e.Len, 1)))
+	return b.String()
+}
+
+// FormatAll renders every error.
+func (src Source) FormatAll(errs []Error) []string {
+	out := make([]string, 0, len(errs))
+	for _, e := range errs {
+		out = append(out, src.Format(e))
+	}
+	return out
+}
+
+// line returns the n-th line of the source (1-based) without the line break.
+func (src Source) line(n int) (string, bool) {
+	lines := strings.Split(src.Text, "\n")
+	if n < 1 || n > len(lines) {
+		return "", false
+	}
+	return strings.TrimRight(lines[n-1], "\r"), true
+}
+
+// caretIndent returns the whitespace placing a caret under column col,
+// tabs are kept so the caret lines up with the excerpt.
+func caretIndent(line string, col int) string {
+	var b strings.Builder
+	for i := 0; i < col-1; i++ {
+		if i < len(line) && line[i] == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
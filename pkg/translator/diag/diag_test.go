@@ -0,0 +1,44 @@
+package diag_test
+
+import (
+	"testing"
+
+	"github.com/awesoma31/csa-lab4/pkg/translator/diag"
+	"github.com/awesoma31/csa-lab4/pkg/translator/lexer"
+)
+
+func TestFormat(t *testing.T) {
+	src := diag.Source{File: "prog.lang", Text: "let a = 1;\n\tprint(b);\n"}
+
+	for _, tc := range []struct {
+		name string
+		err  diag.Error
+		want string
+	}{
+		{
+			name: "caret under the token",
+			err:  diag.Error{Pos: lexer.Pos{Line: 2, Col: 8}, Len: 1, Msg: "Undeclared variable: b"},
+			want: "prog.lang:2:8: Undeclared variable: b\n" +
+				"  2 | \tprint(b);\n" +
+				"    | \t      ^",
+		},
+		{
+			name: "underline spans the token",
+			err:  diag.Error{Pos: lexer.Pos{Line: 1, Col: 1}, Len: 3, Msg: "unexpected let"},
+			want: "prog.lang:1:1: unexpected let\n" +
+				"  1 | let a = 1;\n" +
+				"    | ^^^",
+		},
+		{
+			name: "no position",
+			err:  diag.Error{Msg: "heap overflow"},
+			want: "prog.lang: heap overflow",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := src.Format(tc.err); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
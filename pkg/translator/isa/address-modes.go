@@ -15,7 +15,7 @@ const (
 
 	ImmReg uint32 = 0x0B
 
-	// 0x0C
+	MvCauseReg uint32 = 0x0C // rd<-CAUSE
 	// 0x0D
 
 	RegReg            uint32 = 0x0E
@@ -30,6 +30,7 @@ const (
 	LongM  uint32 = 0x16
 
 	JAbsAddr      uint32 = 0x18
+	JReg          uint32 = 0x19 // PC<-rs1
 	SingleRegMode uint32 = 0x1C
 
 	// 0x1D
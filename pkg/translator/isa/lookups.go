@@ -17,15 +17,25 @@ func init() {
 		OpPush: "PUSH",
 		OpPop:  "POP",
 
-		OpHalt: "HALT",
+		OpHalt:  "HALT",
+		OpBound: "BOUND",
 
 		OpAdd: "ADD",
 		OpSub: "SUB",
 		OpMul: "MUL",
 		OpDiv: "DIV",
+		OpRem: "REM",
+		OpAdc: "ADC",
+		OpSbc: "SBC",
 
 		OpCmp: "CMP",
 		OpAnd: "AND",
+		OpOr:  "OR",
+		OpXor: "XOR",
+		OpNot: "NOT",
+		OpShl: "SHL",
+		OpShr: "SHR",
+		OpSar: "SAR",
 
 		OpIn: "IN", OpOut: "OUT",
 
@@ -33,7 +43,9 @@ func init() {
 		OpIntOn:  "IntOn",
 		OpIntOff: "IntOff",
 
-		OpJmp: "JMP",
+		OpJmp:  "JMP",
+		OpCall: "CALL",
+		OpRet:  "RET",
 
 		OpJe: "JE", OpJne: "JNE", OpJg: "JG", OpJl: "JL",
 		OpJge: "JGE", OpJle: "JLE", OpJa: "JA", OpJb: "JB",
@@ -51,6 +63,7 @@ func init() {
 		MvRegLowToMem:     "MvRegLowMem",
 		MvImmMem:          "MvImmMem",
 		MvRegToRegInd:     "MvRegToRegInd",
+		MvCauseReg:        "MvCauseReg",
 		ImmReg:            "ImmReg",
 		RegReg:            "RegReg",
 		MvByteRegIndToReg: "MvLowRegIndToReg",
@@ -63,6 +76,7 @@ func init() {
 		MathRIR: "MathRIR",
 
 		JAbsAddr:      "JAbsAddr",
+		JReg:          "JReg",
 		SingleRegMode: "SingleReg",
 
 		NoOperands: "NoOperands",
@@ -6,12 +6,17 @@ const (
 	OpPush uint32 = 0x02
 	OpPop  uint32 = 0x03
 	OpHalt uint32 = 0x06
+	// traps unless rs1 < rs2 unsigned: the index in rs1, the length in rs2
+	OpBound uint32 = 0x07
 
 	OpAdd uint32 = 0x10
 	OpSub uint32 = 0x11
 	OpMul uint32 = 0x12
 	OpDiv uint32 = 0x13
 	OpCmp uint32 = 0x14
+	OpRem uint32 = 0x15
+	OpAdc uint32 = 0x16 // add with carry: rd <- rs1 + rs2 + C
+	OpSbc uint32 = 0x17 // subtract with borrow: rd <- rs1 - rs2 - !C
 
 	OpIn uint32 = 0x18
 	// 0x19
@@ -21,8 +26,16 @@ const (
 	OpIntOff uint32 = 0x1D
 
 	OpJmp  uint32 = 0x20
+	OpCall uint32 = 0x21
+	OpRet  uint32 = 0x22
 	OpAnd  uint32 = 0x23
 	OpIRet uint32 = 0x24
+	OpOr   uint32 = 0x25
+	OpXor  uint32 = 0x26
+	OpNot  uint32 = 0x27
+	OpShl  uint32 = 0x28
+	OpShr  uint32 = 0x29
+	OpSar  uint32 = 0x2A
 
 	OpJe  uint32 = 0x30
 	OpJne uint32 = 0x31
@@ -0,0 +1,33 @@
+package isa
+
+// ─────────────────────────── traps ───────────────────────────
+// A trap is raised by the instruction being executed. The vector of
+// trap N is in instruction memory right after the interruption vectors,
+// at max_interruptions+N, the program starts after the trap vectors.
+const (
+	TrapDivZero        uint32 = iota // DIV or REM by zero
+	TrapIllegal                      // unknown opcode or address mode
+	TrapMemFault                     // data access or fetch outside memory
+	TrapStackOverflow                // PUSH or CALL below the stack area
+	TrapBounds                       // BOUND: index out of range
+	TrapStackUnderflow               // POP or RET from an empty stack
+
+	TrapCount
+)
+
+var trapMnemonics = map[uint32]string{
+	TrapDivZero:        "division by zero",
+	TrapIllegal:        "illegal instruction",
+	TrapMemFault:       "memory fault",
+	TrapStackOverflow:  "stack overflow",
+	TrapBounds:         "index out of range",
+	TrapStackUnderflow: "stack underflow",
+}
+
+// GetTrapMnem returns the description of the trap cause used in reports.
+func GetTrapMnem(cause uint32) string {
+	if s, ok := trapMnemonics[cause]; ok {
+		return s
+	}
+	return "unknown trap"
+}
@@ -36,17 +36,29 @@ const (
 	OR
 	AND
 
+	AMPERSAND
+	PIPE
+	CARET
+	TILDE
+	ShiftLeft
+	ShiftRight
+	ShiftRightUnsigned
+
 	DOT
 	DotDot
 	SemiColon
 	COLON
 	QUESTION
 	COMMA
+	FatArrow
 
 	PlusPlus
 	MinusMinus
 	PlusEquals
 	MinusEquals
+	StarEquals
+	SlashEquals
+	PercentEquals
 	NullishAssignment
 
 	PLUS
@@ -57,17 +69,30 @@ const (
 
 	// Reserved Keywords
 	LET
+	CONST
 	IF
 	ELSE
 	WHILE
+	FOR
+	IN
+	BREAK
+	CONTINUE
+	FN
+	RETURN
+	MATCH
 
 	INTER
 	IntOn
 	IntOff
+	TRAP
 
 	PRINT
 	READCH
 	READINT
+	READLONG
+	TRAPCAUSE
+	ALLOC
+	FREE
 	ADDSTR
 	ADDL
 
@@ -78,26 +103,61 @@ const (
 
 // RESERVED WORDS FOUND HERE
 var reservedLu = map[string]TokenKind{
-	"true":    TRUE,
-	"false":   FALSE,
-	"let":     LET,
-	"if":      IF,
-	"else":    ELSE,
-	"while":   WHILE,
-	"print":   PRINT,
-	"read":    READCH,
-	"readInt": READINT,
-	"inter":   INTER,
-	"intOn":   IntOn,
-	"intOff":  IntOff,
-	"list":    LIST,
-	"addStr":  ADDSTR,
-	"addL":    ADDL,
+	"true":      TRUE,
+	"false":     FALSE,
+	"let":       LET,
+	"const":     CONST,
+	"if":        IF,
+	"else":      ELSE,
+	"while":     WHILE,
+	"for":       FOR,
+	"in":        IN,
+	"break":     BREAK,
+	"continue":  CONTINUE,
+	"fn":        FN,
+	"return":    RETURN,
+	"match":     MATCH,
+	"print":     PRINT,
+	"read":      READCH,
+	"readInt":   READINT,
+	"readLong":  READLONG,
+	"inter":     INTER,
+	"intOn":     IntOn,
+	"intOff":    IntOff,
+	"trap":      TRAP,
+	"trapCause": TRAPCAUSE,
+	"alloc":     ALLOC,
+	"free":      FREE,
+	"list":      LIST,
+	"addStr":    ADDSTR,
+	"addL":      ADDL,
+}
+
+// Pos is a position in the source, Line and Col start from 1, Offset is a byte offset.
+type Pos struct {
+	Line   int
+	Col    int
+	Offset int
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
+
+// IsValid reports whether the position was set.
+func (p Pos) IsValid() bool {
+	return p.Line > 0
 }
 
 type Token struct {
 	Kind  TokenKind
 	Value string
+	Pos   Pos // position of the first character, the token spans len(Value) bytes
+}
+
+// End returns the position right after the last character of the token.
+func (tk Token) End() Pos {
+	return Pos{Line: tk.Pos.Line, Col: tk.Pos.Col + len(tk.Value), Offset: tk.Pos.Offset + len(tk.Value)}
 }
 
 func (tk Token) IsOneOfMany(expectedTokens ...TokenKind) bool {
@@ -160,6 +220,20 @@ func TokenKindString(kind TokenKind) string {
 		return "or"
 	case AND:
 		return "and"
+	case AMPERSAND:
+		return "ampersand"
+	case PIPE:
+		return "pipe"
+	case CARET:
+		return "caret"
+	case TILDE:
+		return "tilde"
+	case ShiftLeft:
+		return "shift_left"
+	case ShiftRight:
+		return "shift_right"
+	case ShiftRightUnsigned:
+		return "shift_right_unsigned"
 	case DOT:
 		return "dot"
 	case DotDot:
@@ -170,6 +244,8 @@ func TokenKindString(kind TokenKind) string {
 		return "colon"
 	case COMMA:
 		return "comma"
+	case FatArrow:
+		return "fat_arrow"
 	case PlusPlus:
 		return "plus_plus"
 	case MinusMinus:
@@ -178,6 +254,12 @@ func TokenKindString(kind TokenKind) string {
 		return "plus_equals"
 	case MinusEquals:
 		return "minus_equals"
+	case StarEquals:
+		return "star_equals"
+	case SlashEquals:
+		return "slash_equals"
+	case PercentEquals:
+		return "percent_equals"
 	case NullishAssignment:
 		return "nullish_assignment"
 	case PLUS:
@@ -192,16 +274,44 @@ func TokenKindString(kind TokenKind) string {
 		return "percent"
 	case LET:
 		return "let"
+	case CONST:
+		return "const"
 	case IF:
 		return "if"
 	case ELSE:
 		return "else"
 	case WHILE:
 		return "while"
+	case FOR:
+		return "for"
+	case IN:
+		return "in"
+	case BREAK:
+		return "break"
+	case CONTINUE:
+		return "continue"
+	case FN:
+		return "fn"
+	case RETURN:
+		return "return"
+	case MATCH:
+		return "match"
 	case PRINT:
 		return "print"
 	case READCH:
 		return "read"
+	case READINT:
+		return "readInt"
+	case READLONG:
+		return "readLong"
+	case TRAP:
+		return "trap"
+	case TRAPCAUSE:
+		return "trapCause"
+	case ALLOC:
+		return "alloc"
+	case FREE:
+		return "free"
 	case ADDSTR:
 		return "addStr"
 	case ADDL:
@@ -211,8 +321,8 @@ func TokenKindString(kind TokenKind) string {
 	}
 }
 
-func newUniqueToken(kind TokenKind, value string) Token {
+func newUniqueToken(kind TokenKind, value string, pos Pos) Token {
 	return Token{
-		kind, value,
+		kind, value, pos,
 	}
 }
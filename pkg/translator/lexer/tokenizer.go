@@ -3,6 +3,7 @@ package lexer
 import (
 	"fmt"
 	"regexp"
+	"unicode/utf8"
 )
 
 type regexPattern struct {
@@ -11,18 +12,35 @@ type regexPattern struct {
 }
 
 type lexer struct {
-	patterns []regexPattern
-	Tokens   []Token
-	source   string
-	pos      int
-	line     int
+	patterns  []regexPattern
+	Tokens    []Token
+	source    string
+	pos       int
+	line      int
+	lineStart int // offset of the first character of the current line
+	start     Pos // position of the token being matched
+	errors    []Error
 }
 
-func Tokenize(source string) []Token {
+// Error is a lexical error, Len characters starting at Pos were not recognized.
+type Error struct {
+	Pos Pos
+	Len int
+	Msg string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%v: %s", e.Pos, e.Msg)
+}
+
+// Tokenize splits source into tokens. Unrecognized characters are skipped and
+// reported, so the tokens are always terminated by EOF.
+func Tokenize(source string) ([]Token, []Error) {
 	lex := createLexer(source)
 
 	for !lex.atEof() {
 		matched := false
+		lex.start = lex.position()
 
 		for _, pattern := range lex.patterns {
 			loc := pattern.regex.FindStringIndex(lex.remainder())
@@ -34,18 +52,51 @@ func Tokenize(source string) []Token {
 		}
 
 		if !matched {
-			panic(fmt.Sprintf("lexer error: unrecognized token near '%v'", lex.remainder()))
+			lex.skipUnrecognized()
 		}
 	}
 
-	lex.push(newUniqueToken(EOF, "EOF"))
-	return lex.Tokens
+	lex.start = lex.position()
+	lex.push(newUniqueToken(EOF, "EOF", lex.start))
+	return lex.Tokens, lex.errors
+}
+
+// skipUnrecognized skips one character that starts no token, a run of such
+// characters is reported as a single error.
+func (lex *lexer) skipUnrecognized() {
+	_, size := utf8.DecodeRuneInString(lex.remainder())
+	if n := len(lex.errors); n > 0 {
+		if last := &lex.errors[n-1]; last.Pos.Offset+last.Len == lex.pos {
+			last.Len += size
+			last.Msg = fmt.Sprintf("unrecognized characters %q", lex.source[last.Pos.Offset:lex.pos+size])
+			lex.advanceN(size)
+			return
+		}
+	}
+	lex.errors = append(lex.errors, Error{
+		Pos: lex.start,
+		Len: size,
+		Msg: fmt.Sprintf("unrecognized character %q", lex.remainder()[:size]),
+	})
+	lex.advanceN(size)
 }
 
+// advanceN skips n bytes, keeping track of line starts.
 func (lex *lexer) advanceN(n int) {
+	for i := lex.pos; i < lex.pos+n && i < len(lex.source); i++ {
+		if lex.source[i] == '\n' {
+			lex.line++
+			lex.lineStart = i + 1
+		}
+	}
 	lex.pos += n
 }
 
+// position returns the current position of the lexer.
+func (lex *lexer) position() Pos {
+	return Pos{Line: lex.line, Col: lex.pos - lex.lineStart + 1, Offset: lex.pos}
+}
+
 func (lex *lexer) remainder() string {
 	return lex.source[lex.pos:]
 }
@@ -77,15 +128,23 @@ func createLexer(source string) *lexer {
 			{regexp.MustCompile(`\(`), defaultHandler(OpenParen, "(")},
 			{regexp.MustCompile(`\)`), defaultHandler(CloseParen, ")")},
 			{regexp.MustCompile(`==`), defaultHandler(EQUALS, "==")},
+			{regexp.MustCompile(`=>`), defaultHandler(FatArrow, "=>")},
 			{regexp.MustCompile(`!=`), defaultHandler(NotEquals, "!=")},
 			{regexp.MustCompile(`=`), defaultHandler(ASSIGNMENT, "=")},
 			{regexp.MustCompile(`!`), defaultHandler(NOT, "!")},
+			{regexp.MustCompile(`<<`), defaultHandler(ShiftLeft, "<<")},
 			{regexp.MustCompile(`<=`), defaultHandler(LessEquals, "<=")},
 			{regexp.MustCompile(`<`), defaultHandler(LESS, "<")},
+			{regexp.MustCompile(`>>>`), defaultHandler(ShiftRightUnsigned, ">>>")},
+			{regexp.MustCompile(`>>`), defaultHandler(ShiftRight, ">>")},
 			{regexp.MustCompile(`>=`), defaultHandler(GreaterEquals, ">=")},
 			{regexp.MustCompile(`>`), defaultHandler(GREATER, ">")},
 			{regexp.MustCompile(`\|\|`), defaultHandler(OR, "||")},
 			{regexp.MustCompile(`&&`), defaultHandler(AND, "&&")},
+			{regexp.MustCompile(`\|`), defaultHandler(PIPE, "|")},
+			{regexp.MustCompile(`&`), defaultHandler(AMPERSAND, "&")},
+			{regexp.MustCompile(`\^`), defaultHandler(CARET, "^")},
+			{regexp.MustCompile(`~`), defaultHandler(TILDE, "~")},
 			{regexp.MustCompile(`\.\.`), defaultHandler(DotDot, "..")},
 			{regexp.MustCompile(`\.`), defaultHandler(DOT, ".")},
 			{regexp.MustCompile(`;`), defaultHandler(SemiColon, ";")},
@@ -97,6 +156,9 @@ func createLexer(source string) *lexer {
 			{regexp.MustCompile(`--`), defaultHandler(MinusMinus, "--")},
 			{regexp.MustCompile(`\+=`), defaultHandler(PlusEquals, "+=")},
 			{regexp.MustCompile(`-=`), defaultHandler(MinusEquals, "-=")},
+			{regexp.MustCompile(`\*=`), defaultHandler(StarEquals, "*=")},
+			{regexp.MustCompile(`/=`), defaultHandler(SlashEquals, "/=")},
+			{regexp.MustCompile(`%=`), defaultHandler(PercentEquals, "%=")},
 			{regexp.MustCompile(`\+`), defaultHandler(PLUS, "+")},
 			{regexp.MustCompile(`-`), defaultHandler(MINUS, "-")},
 			{regexp.MustCompile(`/`), defaultHandler(SLASH, "/")},
@@ -112,7 +174,7 @@ type regexHandler func(lex *lexer, regex *regexp.Regexp)
 func defaultHandler(kind TokenKind, value string) regexHandler {
 	return func(lex *lexer, _ *regexp.Regexp) {
 		lex.advanceN(len(value))
-		lex.push(newUniqueToken(kind, value))
+		lex.push(newUniqueToken(kind, value, lex.start))
 	}
 }
 
@@ -120,13 +182,13 @@ func stringHandler(lex *lexer, regex *regexp.Regexp) {
 	match := regex.FindStringIndex(lex.remainder())
 	stringLiteral := lex.remainder()[match[0]:match[1]]
 
-	lex.push(newUniqueToken(STRING, stringLiteral))
+	lex.push(newUniqueToken(STRING, stringLiteral, lex.start))
 	lex.advanceN(len(stringLiteral))
 }
 
 func numberHandler(lex *lexer, regex *regexp.Regexp) {
 	match := regex.FindString(lex.remainder())
-	lex.push(newUniqueToken(NUMBER, match))
+	lex.push(newUniqueToken(NUMBER, match, lex.start))
 	lex.advanceN(len(match))
 }
 
@@ -134,9 +196,9 @@ func symbolHandler(lex *lexer, regex *regexp.Regexp) {
 	match := regex.FindString(lex.remainder())
 
 	if kind, found := reservedLu[match]; found {
-		lex.push(newUniqueToken(kind, match))
+		lex.push(newUniqueToken(kind, match, lex.start))
 	} else {
-		lex.push(newUniqueToken(IDENTIFIER, match))
+		lex.push(newUniqueToken(IDENTIFIER, match, lex.start))
 	}
 
 	lex.advanceN(len(match))
@@ -150,9 +212,8 @@ func skipHandler(lex *lexer, regex *regexp.Regexp) {
 func commentHandler(lex *lexer, regex *regexp.Regexp) {
 	match := regex.FindStringIndex(lex.remainder())
 	if match != nil {
-		// Advance past the entire comment.
+		// Advance past the entire comment, the line break is skipped as whitespace.
 		lex.advanceN(match[1])
-		lex.line++
 	}
 }
 
@@ -33,7 +33,7 @@ func parseExpr(p *parser, rbp bindingPower) ast.Expr {
 
 		if !exists {
 			p.addError(fmt.Sprintf("LED Handler expected for token %s\n", lexer.TokenKindString(tokenKind)))
-			panic(p.errors[len(p.errors)-1])
+			return left
 		}
 
 		// Call the Left Denotation (LED), passing the already parsed 'left' expression.
@@ -53,6 +53,7 @@ func parsePrefixExpr(p *parser) ast.Expr {
 	expr := parseExpr(p, unary)
 
 	return ast.PrefixExpr{
+		Node:     ast.Node{Pos: operatorToken.Pos},
 		Operator: operatorToken,
 		Right:    expr,
 	}
@@ -70,38 +71,92 @@ func parseAssignmentExpr(p *parser, left ast.Expr) ast.Expr {
 	rhs := parseExpr(p, bpLu[operatorToken.Kind]-1)
 
 	return ast.AssignmentExpr{
+		Node:          ast.Node{Pos: posOf(left, operatorToken)},
 		Assigne:       left,
+		Operator:      operatorToken,
 		AssignedValue: rhs,
 	}
 }
 
+// parseTernaryExpr handles `cond ? a : b`. It is right-associative, so
+// `a ? b : c ? d : e` parses as `a ? b : (c ? d : e)`; the middle operand
+// is delimited by `:` and may be any expression.
+func parseTernaryExpr(p *parser, cond ast.Expr) ast.Expr {
+	questionToken := p.advance()
+	consequent := parseExpr(p, defaultBp)
+	p.expect(lexer.COLON)
+	alternate := parseExpr(p, ternary-1)
+
+	return ast.TernaryExpr{
+		Node:       ast.Node{Pos: posOf(cond, questionToken)},
+		Condition:  cond,
+		Consequent: consequent,
+		Alternate:  alternate,
+	}
+}
+
 func parseReadChEx(p *parser) ast.Expr {
-	p.expect(lexer.READCH)
+	tok := p.expect(lexer.READCH)
 	p.expect(lexer.OpenParen)
 	p.expect(lexer.CloseParen)
-	return ast.ReadChExpr{}
+	return ast.ReadChExpr{Node: ast.Node{Pos: tok.Pos}}
 }
 func parseReadIntEx(p *parser) ast.Expr {
-	p.expect(lexer.READINT)
+	tok := p.expect(lexer.READINT)
+	p.expect(lexer.OpenParen)
+	p.expect(lexer.CloseParen)
+	return ast.ReadIntExpr{Node: ast.Node{Pos: tok.Pos}}
+}
+func parseTrapCauseEx(p *parser) ast.Expr {
+	tok := p.expect(lexer.TRAPCAUSE)
+	p.expect(lexer.OpenParen)
+	p.expect(lexer.CloseParen)
+	return ast.TrapCauseExpr{Node: ast.Node{Pos: tok.Pos}}
+}
+func parseReadLongEx(p *parser) ast.Expr {
+	tok := p.expect(lexer.READLONG)
 	p.expect(lexer.OpenParen)
 	p.expect(lexer.CloseParen)
-	return ast.ReadIntExpr{}
+	return ast.ReadLongExpr{Node: ast.Node{Pos: tok.Pos}}
 }
 
 func parseListEx(p *parser) ast.Expr {
-	p.expect(lexer.LIST)
+	tok := p.expect(lexer.LIST)
 	p.expect(lexer.OpenParen)
-	arg := parseExpr(p, primary)
-	var n int
-	switch a := arg.(type) {
-	case ast.NumberExpr:
-		n = int(a.Value)
-	default:
-		p.addError(fmt.Sprintf("list argument should be a number, got %v", a))
-		return nil
+	arg := parseExpr(p, defaultBp)
+	p.expect(lexer.CloseParen)
+	list := ast.ListEx{Node: ast.Node{Pos: tok.Pos}}
+	if a, ok := arg.(ast.NumberExpr); ok {
+		list.Size = int(a.Value)
+	} else {
+		// a constant, checked and evaluated by sema
+		list.SizeExpr = arg
 	}
+	return list
+}
+
+// parseAllocEx parses `alloc(n)`, the size is any int expression.
+func parseAllocEx(p *parser) ast.Expr {
+	tok := p.expect(lexer.ALLOC)
+	p.expect(lexer.OpenParen)
+	size := parseExpr(p, defaultBp)
 	p.expect(lexer.CloseParen)
-	return ast.ListEx{Size: n}
+	return ast.AllocExpr{Node: ast.Node{Pos: tok.Pos}, Size: size}
+}
+
+// parseArrayLiteral parses `[a, b, c]`.
+func parseArrayLiteral(p *parser) ast.Expr {
+	tok := p.expect(lexer.OpenBracket)
+	lit := ast.ArrayLiteral{Node: ast.Node{Pos: tok.Pos}}
+	for p.hasTokens() && p.currentTokenKind() != lexer.CloseBracket && !p.panicking {
+		lit.Contents = append(lit.Contents, parseExpr(p, comma))
+
+		if p.currentTokenKind() != lexer.CloseBracket {
+			p.expect(lexer.COMMA)
+		}
+	}
+	p.expect(lexer.CloseBracket)
+	return lit
 }
 
 // parseBinaryExpr handles standard binary operators (+, -, *, /, ==, <, etc.)
@@ -111,6 +166,7 @@ func parseBinaryExpr(p *parser, left ast.Expr) ast.Expr {
 	right := parseExpr(p, bpLu[operatorToken.Kind])
 
 	return ast.BinaryExpr{
+		Node:     ast.Node{Pos: posOf(left, operatorToken)},
 		Left:     left,
 		Operator: operatorToken,
 		Right:    right,
@@ -127,20 +183,38 @@ func parsePrimaryExpr(p *parser) ast.Expr {
 			if err != nil {
 				p.addError(fmt.Sprintf("Failed to parse number: %v", err))
 			}
-			return ast.LongNumberExpr{Value: number}
+			return ast.LongNumberExpr{Node: ast.Node{Pos: tok.Pos}, Value: number}
 
 		}
 		return ast.NumberExpr{
+			Node:  ast.Node{Pos: tok.Pos},
 			Value: int32(number),
 		}
 	case lexer.STRING:
-		s := strings.Trim(p.advance().Value, `"`)
+		tok := p.advance()
 		return ast.StringExpr{
-			Value: s,
+			Node:  ast.Node{Pos: tok.Pos},
+			Value: strings.Trim(tok.Value, `"`),
+		}
+	case lexer.TRUE, lexer.FALSE:
+		tok := p.advance()
+		return ast.BoolExpr{
+			Node:  ast.Node{Pos: tok.Pos},
+			Value: tok.Kind == lexer.TRUE,
 		}
 	case lexer.IDENTIFIER:
 		identTok := p.advance()
-		sym := ast.SymbolExpr{Value: identTok.Value}
+		node := ast.Node{Pos: identTok.Pos}
+		sym := ast.SymbolExpr{Node: node, Value: identTok.Value}
+
+		/* ---------- f(a, b) ---------- */
+		if p.currentTokenKind() == lexer.OpenParen {
+			return ast.CallExpr{
+				Node: node,
+				Name: identTok.Value,
+				Args: parseCallArgs(p),
+			}
+		}
 
 		/* ---------- arr[i] ---------- */
 		if p.currentTokenKind() == lexer.OpenBracket {
@@ -148,6 +222,7 @@ func parsePrimaryExpr(p *parser) ast.Expr {
 			idx := parseExpr(p, defaultBp) // expr
 			p.expect(lexer.CloseBracket)
 			return ast.ArrayIndexEx{
+				Node:   node,
 				Target: sym,
 				Index:  idx,
 			}
@@ -156,9 +231,25 @@ func parsePrimaryExpr(p *parser) ast.Expr {
 		return sym
 	default:
 		p.addError(fmt.Sprintf("Cannot create primary_expr from %s\n", lexer.TokenKindString(p.currentTokenKind())))
-		return ast.StringExpr{}
+		return ast.StringExpr{Node: ast.Node{Pos: p.currentPos()}}
 	}
 }
+
+// parseCallArgs parses a parenthesised, comma separated argument list.
+func parseCallArgs(p *parser) []ast.Expr {
+	p.expect(lexer.OpenParen)
+	args := make([]ast.Expr, 0)
+	for p.hasTokens() && p.currentTokenKind() != lexer.CloseParen && !p.panicking {
+		args = append(args, parseExpr(p, comma))
+
+		if p.currentTokenKind() != lexer.CloseParen {
+			p.expect(lexer.COMMA)
+		}
+	}
+	p.expect(lexer.CloseParen)
+	return args
+}
+
 func parseAddStrExpr(p *parser) ast.Expr {
 	nameTok := p.expect(lexer.ADDSTR)
 	p.expect(lexer.OpenParen)
@@ -168,6 +259,7 @@ func parseAddStrExpr(p *parser) ast.Expr {
 
 	p.expect(lexer.CloseParen)
 	return ast.CallExpr{
+		Node: ast.Node{Pos: nameTok.Pos},
 		Name: nameTok.Value,
 		Args: []ast.Expr{arg1, arg2},
 	}
@@ -181,6 +273,7 @@ func parseAddLExpr(p *parser) ast.Expr {
 
 	p.expect(lexer.CloseParen)
 	return ast.CallExpr{
+		Node: ast.Node{Pos: nameTok.Pos},
 		Name: nameTok.Value,
 		Args: []ast.Expr{arg1, arg2},
 	}
@@ -192,3 +285,11 @@ func parseGroupingExpr(p *parser) ast.Expr {
 	p.expect(lexer.CloseParen)
 	return expr
 }
+
+// posOf returns the position of expr, falling back to tok when expr is missing after an error.
+func posOf(expr ast.Expr, tok lexer.Token) lexer.Pos {
+	if expr == nil {
+		return tok.Pos
+	}
+	return expr.Position()
+}
@@ -10,12 +10,18 @@ type bindingPower int
 const (
 	defaultBp      bindingPower = iota // 0 - lowest possible precedence
 	comma                              // ,
-	assignment                         // =, +=, -=
-	logical                            // AND, OR
+	assignment                         // =, +=, -=, *=, /=, %=
+	ternary                            // ?:
+	logicalOr                          // ||
+	logicalAnd                         // &&
+	bitwiseOr                          // |
+	bitwiseXor                         // ^
+	bitwiseAnd                         // &
 	relational                         // <, <=, >, >=, ==, !=
+	shift                              // <<, >>, >>>
 	additive                           // +, -
 	multiplicative                     // *, /, %
-	unary                              // !, -, typeof (prefix)
+	unary                              // !, -, ~ (prefix)
 	primary                            // Literals, Identifiers, Grouping ()
 )
 
@@ -56,10 +62,21 @@ func createTokenLookups() {
 	led(lexer.ASSIGNMENT, assignment, parseAssignmentExpr)
 	led(lexer.PlusEquals, assignment, parseAssignmentExpr)
 	led(lexer.MinusEquals, assignment, parseAssignmentExpr)
+	led(lexer.StarEquals, assignment, parseAssignmentExpr)
+	led(lexer.SlashEquals, assignment, parseAssignmentExpr)
+	led(lexer.PercentEquals, assignment, parseAssignmentExpr)
+
+	// Conditional Operator (Right-Associative)
+	led(lexer.QUESTION, ternary, parseTernaryExpr)
 
 	// Logical Operators (Left-Associative)
-	led(lexer.AND, logical, parseBinaryExpr)
-	led(lexer.OR, logical, parseBinaryExpr)
+	led(lexer.OR, logicalOr, parseBinaryExpr)
+	led(lexer.AND, logicalAnd, parseBinaryExpr)
+
+	// Bitwise Operators (Left-Associative)
+	led(lexer.PIPE, bitwiseOr, parseBinaryExpr)
+	led(lexer.CARET, bitwiseXor, parseBinaryExpr)
+	led(lexer.AMPERSAND, bitwiseAnd, parseBinaryExpr)
 
 	// Relational Operators (Left-Associative)
 	led(lexer.LESS, relational, parseBinaryExpr)
@@ -69,6 +86,11 @@ func createTokenLookups() {
 	led(lexer.EQUALS, relational, parseBinaryExpr)
 	led(lexer.NotEquals, relational, parseBinaryExpr)
 
+	// Shift Operators (Left-Associative)
+	led(lexer.ShiftLeft, shift, parseBinaryExpr)
+	led(lexer.ShiftRight, shift, parseBinaryExpr)
+	led(lexer.ShiftRightUnsigned, shift, parseBinaryExpr)
+
 	// Additive Operators (Left-Associative)
 	led(lexer.PLUS, additive, parseBinaryExpr)
 	led(lexer.MINUS, additive, parseBinaryExpr) // For binary minus
@@ -81,6 +103,8 @@ func createTokenLookups() {
 	// Literals & Symbols (NUDs - they start expressions)
 	nud(lexer.NUMBER, parsePrimaryExpr)
 	nud(lexer.STRING, parsePrimaryExpr)
+	nud(lexer.TRUE, parsePrimaryExpr)
+	nud(lexer.FALSE, parsePrimaryExpr)
 	nud(lexer.IDENTIFIER, parsePrimaryExpr)
 	nud(lexer.ADDSTR, parseAddStrExpr)
 	nud(lexer.ADDL, parseAddLExpr)
@@ -90,23 +114,37 @@ func createTokenLookups() {
 	// ensuring `!x + y` parses as `(!x) + y`.
 	nud(lexer.MINUS, parsePrefixExpr)
 	nud(lexer.NOT, parsePrefixExpr)
+	nud(lexer.TILDE, parsePrefixExpr)
 
 	// Grouping Expression (NUD - starts a grouped expression)
 	// Parentheses themselves define a grouping, their NUD handles parsing the inner expression.
 	nud(lexer.OpenParen, parseGroupingExpr)
+	nud(lexer.OpenBracket, parseArrayLiteral)
 
 	// Built-in fnuctions
 	nud(lexer.READCH, parseReadChEx)
 	nud(lexer.READINT, parseReadIntEx)
+	nud(lexer.TRAPCAUSE, parseTrapCauseEx)
+	nud(lexer.READLONG, parseReadLongEx)
 	nud(lexer.LIST, parseListEx)
+	nud(lexer.ALLOC, parseAllocEx)
 
 	// Statement handlers
 	stmt(lexer.OpenCurly, parseBlockStmt)
 	stmt(lexer.LET, parseVarDeclStmt)
+	stmt(lexer.CONST, parseConstDeclStmt)
 	stmt(lexer.IF, parseIfStmt)
+	stmt(lexer.MATCH, parseMatchStmt)
 	stmt(lexer.WHILE, parseWhileStmt)
+	stmt(lexer.FOR, parseForStmt)
+	stmt(lexer.BREAK, parseBreakStmt)
+	stmt(lexer.CONTINUE, parseContinueStmt)
+	stmt(lexer.FN, parseFnDeclStmt)
+	stmt(lexer.RETURN, parseReturnStmt)
 	stmt(lexer.PRINT, parsePrintStmt)
+	stmt(lexer.FREE, parseFreeStmt)
 	stmt(lexer.INTER, parseInterStmt)
+	stmt(lexer.TRAP, parseTrapStmt)
 	stmt(lexer.IntOn, parseIntOnStmt)
 	stmt(lexer.IntOff, parseIntOffStmt)
 }
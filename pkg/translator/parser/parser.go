@@ -2,63 +2,113 @@ package parser
 
 import (
 	"fmt"
-	"log"
+	"sort"
+	"strings"
 
 	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
+	"github.com/awesoma31/csa-lab4/pkg/translator/diag"
 	"github.com/awesoma31/csa-lab4/pkg/translator/lexer"
-	"github.com/sanity-io/litter"
 )
 
 type parser struct {
 	tokens []lexer.Token
 	pos    int
 
-	errors []string
+	errors    []diag.Error
+	panicking bool // an error was reported in the current statement, see synchronize
 }
 
 func createParser(tokens []lexer.Token) *parser {
 	if len(bpLu) == 0 {
 		createTokenLookups()
-		// createTypeTokenLookups()
+		createTypeTokenLookups()
 	}
 
 	p := &parser{
 		tokens: tokens,
 		pos:    0,
-		errors: make([]string, 0),
+		errors: make([]diag.Error, 0),
 	}
 
 	return p
 }
 
-func (p *parser) addError(errs ...string) {
-	p.errors = append(p.errors, errs...)
+// addError records an error at the current token.
+func (p *parser) addError(msg string) {
+	p.addErrorAt(p.currentPos(), msg)
 }
 
-func (p *parser) Errors() []string {
+// addErrorAt records an error at pos, the current token is underlined when it starts there.
+// Only the first error of a statement is recorded, the rest are usually caused by it.
+func (p *parser) addErrorAt(pos lexer.Pos, msg string) {
+	if p.panicking {
+		return
+	}
+	p.panicking = true
+	length := 1
+	if pos == p.currentPos() {
+		length = p.currentLen()
+	}
+	p.errors = append(p.errors, diag.Error{Pos: pos, Len: length, Msg: strings.TrimSpace(msg)})
+}
+
+func (p *parser) Errors() []diag.Error {
 	return p.errors
 }
 
+// Parse parses a program that has no file name, see ParseFile.
 func Parse(source string) (ast.BlockStmt, []string) {
-	tokens := lexer.Tokenize(source)
+	return ParseFile("<input>", source)
+}
+
+// ParseFile parses source read from file, errors are formatted as
+// file:line:col with an excerpt of the offending line.
+// After an error parsing resumes at the next statement, so every error is reported at once.
+func ParseFile(file, source string) (ast.BlockStmt, []string) {
+	tokens, lexErrs := lexer.Tokenize(source)
 	p := createParser(tokens)
+	for _, e := range lexErrs {
+		p.errors = append(p.errors, diag.Error{Pos: e.Pos, Len: e.Len, Msg: e.Msg})
+	}
 	body := make([]ast.Stmt, 0)
 
 	for p.hasTokens() {
-		stmt := parseStmt(p)
-		if stmt != nil {
+		if stmt := parseStmtSync(p); stmt != nil {
 			body = append(body, stmt)
-		} else {
-			p.advance()
 		}
 	}
 
-	if len(p.errors) > 0 {
-		log.Fatal(litter.Sdump(p.errors))
-	}
+	sortErrors(p.errors)
 	return ast.BlockStmt{
 		Body: body,
-	}, p.Errors()
+	}, diag.Source{File: file, Text: source}.FormatAll(p.errors)
+}
+
+// synchronize skips tokens after an error up to a statement boundary:
+// right after `;`, or before a statement keyword or `}`. At least one token
+// is skipped when the failed statement consumed nothing.
+func (p *parser) synchronize(start int) {
+	p.panicking = false
+	if p.pos == start {
+		p.advance()
+	}
+	for p.hasTokens() {
+		if p.tokens[p.pos-1].Kind == lexer.SemiColon {
+			return
+		}
+		kind := p.currentTokenKind()
+		if _, isStmt := stmtLu[kind]; (isStmt && kind != lexer.OpenCurly) || kind == lexer.CloseCurly {
+			return
+		}
+		p.advance()
+	}
+}
+
+// sortErrors orders errors by position, lexical errors are collected before parsing.
+func sortErrors(errs []diag.Error) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Pos.Offset < errs[j].Pos.Offset
+	})
 }
 
 // currentTokenKind returns the kind of the current token, or EOF if past end.
@@ -69,13 +119,46 @@ func (p *parser) currentTokenKind() lexer.TokenKind {
 	return p.tokens[p.pos].Kind
 }
 
+// peekKind returns the kind of the token after the current one, or EOF if past end.
+func (p *parser) peekKind() lexer.TokenKind {
+	if p.pos+1 >= len(p.tokens) {
+		return lexer.EOF
+	}
+	return p.tokens[p.pos+1].Kind
+}
+
+// currentPos returns the position of the current token, past the end it is
+// the position right after the last token.
+func (p *parser) currentPos() lexer.Pos {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].Kind != lexer.EOF {
+		return p.tokens[p.pos].Pos
+	}
+	return p.prevEnd()
+}
+
+// currentLen returns the length of the current token for underlining.
+func (p *parser) currentLen() int {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].Kind != lexer.EOF {
+		return len(p.tokens[p.pos].Value)
+	}
+	return 1
+}
+
+// prevEnd returns the position right after the last consumed token.
+func (p *parser) prevEnd() lexer.Pos {
+	if p.pos == 0 || len(p.tokens) == 0 {
+		return lexer.Pos{Line: 1, Col: 1}
+	}
+	return p.tokens[min(p.pos, len(p.tokens))-1].End()
+}
+
 // currentToken returns the current token, or an EOF token if past end.
-// func (p *parser) currentToken() lexer.Token {
-// 	if p.pos >= len(p.tokens) {
-// 		return lexer.Token{Kind: lexer.EOF, Value: "EOF"}
-// 	}
-// 	return p.tokens[p.pos]
-// }
+func (p *parser) currentToken() lexer.Token {
+	if p.pos >= len(p.tokens) {
+		return lexer.Token{Kind: lexer.EOF, Value: "EOF"}
+	}
+	return p.tokens[p.pos]
+}
 
 // advance moves the parser to the next token and returns the consumed token.
 func (p *parser) advance() lexer.Token {
@@ -93,9 +176,9 @@ func (p *parser) expect(kind lexer.TokenKind) lexer.Token {
 	if p.currentTokenKind() == kind {
 		return p.advance()
 	}
-	p.addError(fmt.Sprintf("Expected token %s but got %s",
+	p.addErrorAt(p.expectPos(), fmt.Sprintf("Expected token %s but got %s",
 		lexer.TokenKindString(kind), lexer.TokenKindString(p.currentTokenKind())))
-	return lexer.Token{Kind: lexer.UNKNOWN, Value: "ERROR"}
+	return lexer.Token{Kind: lexer.UNKNOWN, Value: "ERROR", Pos: p.currentPos()}
 }
 
 // expectError is a specialized expect that allows custom error messages.
@@ -103,8 +186,21 @@ func (p *parser) expectError(kind lexer.TokenKind, errMsg string) lexer.Token {
 	if p.currentTokenKind() == kind {
 		return p.advance()
 	}
-	p.addError(errMsg)
-	return lexer.Token{Kind: lexer.UNKNOWN, Value: "ERROR"}
+	p.addErrorAt(p.expectPos(), errMsg)
+	return lexer.Token{Kind: lexer.UNKNOWN, Value: "ERROR", Pos: p.currentPos()}
+}
+
+// expectPos is where a missing token is reported: a token missing at the end
+// of a line (e.g. `;`) is reported right after the previous token rather than
+// at the start of the next line.
+func (p *parser) expectPos() lexer.Pos {
+	cur := p.currentPos()
+	if p.pos > 0 && p.pos <= len(p.tokens) {
+		if prev := p.prevEnd(); prev.Line < cur.Line {
+			return prev
+		}
+	}
+	return cur
 }
 
 // hasTokens checks if there are more tokens to consume (i.e., not at EOF).
@@ -1,14 +1,19 @@
 package parser_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
 	"github.com/awesoma31/csa-lab4/pkg/translator/lexer"
 	"github.com/awesoma31/csa-lab4/pkg/translator/parser"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
+// ignorePos compares trees by shape, positions are checked in TestNodePositions.
+var ignorePos = cmpopts.IgnoreTypes(lexer.Pos{})
+
 const src = `intOff;
 			let a = (5 + 3) * 2 - 10 / 5 + 4;
 			print(a);
@@ -31,7 +36,7 @@ func TestAstBuild(t *testing.T) {
 		},
 	}
 
-	var opts []cmp.Option
+	opts := []cmp.Option{ignorePos}
 
 	if diff := cmp.Diff(want, got, opts...); diff != "" {
 		t.Errorf("AST mismatch (-want +got):\n%s", diff)
@@ -124,3 +129,472 @@ func TestComplexMathExpressionTree(t *testing.T) {
 		t.Fatalf("right operand of + should be symbol b, got %#v", add.Right)
 	}
 }
+
+func TestFunctionDeclaration(t *testing.T) {
+	src := `
+		fn add(a, b) {
+			return a + b;
+		}
+		let c = add(1, 2);
+	`
+
+	prog, errs := parser.Parse(src)
+	if len(errs) != 0 {
+		t.Fatalf("parser returned errors: %v", errs)
+	}
+
+	want := ast.BlockStmt{
+		Body: []ast.Stmt{
+			ast.FunctionDeclarationStmt{
+				Name:       "add",
+				Parameters: []ast.Parameter{{Name: "a"}, {Name: "b"}},
+				Body: []ast.Stmt{
+					ast.ReturnStmt{Expr: ast.BinaryExpr{
+						Left:     ast.SymbolExpr{Value: "a"},
+						Operator: lexer.Token{Kind: lexer.PLUS, Value: "+"},
+						Right:    ast.SymbolExpr{Value: "b"},
+					}},
+				},
+			},
+			ast.VarDeclarationStmt{
+				Identifier: "c",
+				AssignedValue: ast.CallExpr{
+					Name: "add",
+					Args: []ast.Expr{ast.NumberExpr{Value: 1}, ast.NumberExpr{Value: 2}},
+				},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, prog, ignorePos); diff != "" {
+		t.Errorf("AST mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLogicalPrecedence(t *testing.T) {
+	src := `if !(a < b) || c == 1 && d != 0 {}`
+
+	prog, errs := parser.Parse(src)
+	if len(errs) != 0 {
+		t.Fatalf("parser returned errors: %v", errs)
+	}
+
+	rel := func(l string, kind lexer.TokenKind, op string, r ast.Expr) ast.Expr {
+		return ast.BinaryExpr{
+			Left:     ast.SymbolExpr{Value: l},
+			Operator: lexer.Token{Kind: kind, Value: op},
+			Right:    r,
+		}
+	}
+	want := ast.BlockStmt{
+		Body: []ast.Stmt{
+			ast.IfStmt{
+				Condition: ast.BinaryExpr{
+					Left: ast.PrefixExpr{
+						Operator: lexer.Token{Kind: lexer.NOT, Value: "!"},
+						Right:    rel("a", lexer.LESS, "<", ast.SymbolExpr{Value: "b"}),
+					},
+					Operator: lexer.Token{Kind: lexer.OR, Value: "||"},
+					Right: ast.BinaryExpr{
+						Left:     rel("c", lexer.EQUALS, "==", ast.NumberExpr{Value: 1}),
+						Operator: lexer.Token{Kind: lexer.AND, Value: "&&"},
+						Right:    rel("d", lexer.NotEquals, "!=", ast.NumberExpr{Value: 0}),
+					},
+				},
+				Consequent: ast.BlockStmt{},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, prog, ignorePos); diff != "" {
+		t.Errorf("AST mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBitwisePrecedence(t *testing.T) {
+	src := `let r = a | b ^ c & d << 1 + 1;`
+
+	prog, errs := parser.Parse(src)
+	if len(errs) != 0 {
+		t.Fatalf("parser returned errors: %v", errs)
+	}
+
+	bin := func(l ast.Expr, kind lexer.TokenKind, op string, r ast.Expr) ast.Expr {
+		return ast.BinaryExpr{Left: l, Operator: lexer.Token{Kind: kind, Value: op}, Right: r}
+	}
+	sym := func(name string) ast.Expr { return ast.SymbolExpr{Value: name} }
+
+	// a | (b ^ (c & (d << (1 + 1))))
+	sum := bin(ast.NumberExpr{Value: 1}, lexer.PLUS, "+", ast.NumberExpr{Value: 1})
+	shl := bin(sym("d"), lexer.ShiftLeft, "<<", sum)
+	and := bin(sym("c"), lexer.AMPERSAND, "&", shl)
+	xor := bin(sym("b"), lexer.CARET, "^", and)
+	want := ast.BlockStmt{
+		Body: []ast.Stmt{
+			ast.VarDeclarationStmt{
+				Identifier:    "r",
+				AssignedValue: bin(sym("a"), lexer.PIPE, "|", xor),
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, prog, ignorePos); diff != "" {
+		t.Errorf("AST mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTernary(t *testing.T) {
+	src := `let m = a > b ? a + 1 : c ? d : e;`
+
+	prog, errs := parser.Parse(src)
+	if len(errs) != 0 {
+		t.Fatalf("parser returned errors: %v", errs)
+	}
+
+	bin := func(l ast.Expr, kind lexer.TokenKind, op string, r ast.Expr) ast.Expr {
+		return ast.BinaryExpr{Left: l, Operator: lexer.Token{Kind: kind, Value: op}, Right: r}
+	}
+	sym := func(name string) ast.Expr { return ast.SymbolExpr{Value: name} }
+
+	// (a > b) ? (a + 1) : (c ? d : e)
+	want := ast.BlockStmt{
+		Body: []ast.Stmt{
+			ast.VarDeclarationStmt{
+				Identifier: "m",
+				AssignedValue: ast.TernaryExpr{
+					Condition:  bin(sym("a"), lexer.GREATER, ">", sym("b")),
+					Consequent: bin(sym("a"), lexer.PLUS, "+", ast.NumberExpr{Value: 1}),
+					Alternate:  ast.TernaryExpr{Condition: sym("c"), Consequent: sym("d"), Alternate: sym("e")},
+				},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, prog, ignorePos); diff != "" {
+		t.Errorf("AST mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, errs := parser.Parse("let m = a ? b;"); len(errs) == 0 {
+		t.Errorf("expected a parse error for a missing ':'")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	src := `match cmd { 1 => { print(1); } -2, 3 => { print(2); } _ => { print(0); } }`
+
+	prog, errs := parser.Parse(src)
+	if len(errs) != 0 {
+		t.Fatalf("parser returned errors: %v", errs)
+	}
+
+	body := func(v int32) ast.BlockStmt {
+		return ast.BlockStmt{Body: []ast.Stmt{ast.PrintStmt{Argument: ast.NumberExpr{Value: v}}}}
+	}
+	want := ast.BlockStmt{
+		Body: []ast.Stmt{
+			ast.MatchStmt{
+				Subject: ast.SymbolExpr{Value: "cmd"},
+				Arms: []ast.MatchArm{
+					{Values: []int32{1}, Body: body(1)},
+					{Values: []int32{-2, 3}, Body: body(2)},
+				},
+				Default: body(0),
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, prog, ignorePos); diff != "" {
+		t.Errorf("AST mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, errs := parser.Parse("match x { _ => { } _ => { } }"); len(errs) == 0 {
+		t.Errorf("expected a parse error for a second default arm")
+	}
+	if _, errs := parser.Parse("match x { y => { } }"); len(errs) == 0 {
+		t.Errorf("expected a parse error for a non-literal arm value")
+	}
+}
+
+func TestConstDeclaration(t *testing.T) {
+	src := `const N = 2 * K; let l = list(N);`
+
+	prog, errs := parser.Parse(src)
+	if len(errs) != 0 {
+		t.Fatalf("parser returned errors: %v", errs)
+	}
+
+	want := ast.BlockStmt{
+		Body: []ast.Stmt{
+			ast.ConstDeclarationStmt{
+				Identifier: "N",
+				Value: ast.BinaryExpr{
+					Left:     ast.NumberExpr{Value: 2},
+					Operator: lexer.Token{Kind: lexer.STAR, Value: "*"},
+					Right:    ast.SymbolExpr{Value: "K"},
+				},
+			},
+			ast.VarDeclarationStmt{
+				Identifier:    "l",
+				AssignedValue: ast.ListEx{SizeExpr: ast.SymbolExpr{Value: "N"}},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, prog, ignorePos); diff != "" {
+		t.Errorf("AST mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestArrayLiteral(t *testing.T) {
+	src := `let a = [3, -1, N]; let w: [int; 4];`
+
+	prog, errs := parser.Parse(src)
+	if len(errs) != 0 {
+		t.Fatalf("parser returned errors: %v", errs)
+	}
+
+	want := ast.BlockStmt{
+		Body: []ast.Stmt{
+			ast.VarDeclarationStmt{
+				Identifier: "a",
+				AssignedValue: ast.ArrayLiteral{Contents: []ast.Expr{
+					ast.NumberExpr{Value: 3},
+					ast.PrefixExpr{Operator: lexer.Token{Kind: lexer.MINUS, Value: "-"}, Right: ast.NumberExpr{Value: 1}},
+					ast.SymbolExpr{Value: "N"},
+				}},
+			},
+			ast.VarDeclarationStmt{
+				Identifier:   "w",
+				ExplicitType: ast.ArrayType{Element: ast.IntType, Len: 4},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, prog, ignorePos); diff != "" {
+		t.Errorf("AST mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, errs := parser.Parse("let a = [1, 2;"); len(errs) == 0 {
+		t.Errorf("expected a parse error for an unclosed array literal")
+	}
+}
+
+func TestTrap(t *testing.T) {
+	src := `trap 2 { print(trapCause()); } trap MEM { }`
+
+	prog, errs := parser.Parse(src)
+	if len(errs) != 0 {
+		t.Fatalf("parser returned errors: %v", errs)
+	}
+
+	want := ast.BlockStmt{
+		Body: []ast.Stmt{
+			ast.TrapStmt{
+				Cause: 2,
+				Body: ast.BlockStmt{Body: []ast.Stmt{
+					ast.PrintStmt{Argument: ast.TrapCauseExpr{}},
+				}},
+			},
+			ast.TrapStmt{
+				CauseExpr: ast.SymbolExpr{Value: "MEM"},
+				Body:      ast.BlockStmt{},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, prog, ignorePos); diff != "" {
+		t.Errorf("AST mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAlloc(t *testing.T) {
+	src := `let a: [int] = alloc(n + 1); free(a);`
+
+	prog, errs := parser.Parse(src)
+	if len(errs) != 0 {
+		t.Fatalf("parser returned errors: %v", errs)
+	}
+
+	want := ast.BlockStmt{
+		Body: []ast.Stmt{
+			ast.VarDeclarationStmt{
+				Identifier:   "a",
+				ExplicitType: ast.ListType{Underlying: ast.IntType},
+				AssignedValue: ast.AllocExpr{Size: ast.BinaryExpr{
+					Left:     ast.SymbolExpr{Value: "n"},
+					Operator: lexer.Token{Kind: lexer.PLUS, Value: "+"},
+					Right:    ast.NumberExpr{Value: 1},
+				}},
+			},
+			ast.FreeStmt{Target: ast.SymbolExpr{Value: "a"}},
+		},
+	}
+
+	if diff := cmp.Diff(want, prog, ignorePos); diff != "" {
+		t.Errorf("AST mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCompoundAssignment(t *testing.T) {
+	src := `
+		x *= 2;
+		arr[i]++;
+	`
+
+	prog, errs := parser.Parse(src)
+	if len(errs) != 0 {
+		t.Fatalf("parser returned errors: %v", errs)
+	}
+
+	want := ast.BlockStmt{
+		Body: []ast.Stmt{
+			ast.ExpressionStmt{Expression: ast.AssignmentExpr{
+				Assigne:       ast.SymbolExpr{Value: "x"},
+				Operator:      lexer.Token{Kind: lexer.StarEquals, Value: "*="},
+				AssignedValue: ast.NumberExpr{Value: 2},
+			}},
+			ast.ExpressionStmt{Expression: ast.AssignmentExpr{
+				Assigne: ast.ArrayIndexEx{
+					Target: ast.SymbolExpr{Value: "arr"},
+					Index:  ast.SymbolExpr{Value: "i"},
+				},
+				Operator:      lexer.Token{Kind: lexer.PlusPlus, Value: "++"},
+				AssignedValue: ast.NumberExpr{Value: 1},
+			}},
+		},
+	}
+
+	if diff := cmp.Diff(want, prog, ignorePos); diff != "" {
+		t.Errorf("AST mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestForStatements(t *testing.T) {
+	src := `
+		for i in 0..n {}
+		for x in arr {}
+		for let j = 0; j < 3; j++ {}
+	`
+
+	prog, errs := parser.Parse(src)
+	if len(errs) != 0 {
+		t.Fatalf("parser returned errors: %v", errs)
+	}
+
+	want := ast.BlockStmt{
+		Body: []ast.Stmt{
+			ast.ForeachStmt{
+				Value:    "i",
+				Iterable: ast.RangeExpr{Lower: ast.NumberExpr{Value: 0}, Upper: ast.SymbolExpr{Value: "n"}},
+			},
+			ast.ForeachStmt{
+				Value:    "x",
+				Iterable: ast.SymbolExpr{Value: "arr"},
+			},
+			ast.ForStmt{
+				Init: ast.VarDeclarationStmt{Identifier: "j", AssignedValue: ast.NumberExpr{Value: 0}},
+				Condition: ast.BinaryExpr{
+					Left:     ast.SymbolExpr{Value: "j"},
+					Operator: lexer.Token{Kind: lexer.LESS, Value: "<"},
+					Right:    ast.NumberExpr{Value: 3},
+				},
+				Post: ast.AssignmentExpr{
+					Assigne:       ast.SymbolExpr{Value: "j"},
+					Operator:      lexer.Token{Kind: lexer.PlusPlus, Value: "++"},
+					AssignedValue: ast.NumberExpr{Value: 1},
+				},
+				Body: ast.BlockStmt{},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, prog, ignorePos); diff != "" {
+		t.Errorf("AST mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	prog, pErr := parser.Parse("let a = 1;\nwhile a < 10 {\n\ta += 2;\n}\n")
+	if len(pErr) != 0 {
+		t.Fatalf("parse errors: %v", pErr)
+	}
+
+	decl := prog.Body[0].(ast.VarDeclarationStmt)
+	loop := prog.Body[1].(ast.WhileStmt)
+	assign := loop.Body.(ast.BlockStmt).Body[0].(ast.ExpressionStmt).Expression.(ast.AssignmentExpr)
+
+	for _, tc := range []struct {
+		name string
+		got  lexer.Pos
+		want string
+	}{
+		{"let", decl.Position(), "1:1"},
+		{"initializer", decl.AssignedValue.Position(), "1:9"},
+		{"while", loop.Position(), "2:1"},
+		{"condition", loop.Condition.Position(), "2:7"},
+		{"block", loop.Body.Position(), "2:14"},
+		{"assignment", assign.Position(), "3:2"},
+		{"operator", assign.Operator.Pos, "3:4"},
+		{"value", assign.AssignedValue.Position(), "3:7"},
+	} {
+		if tc.got.String() != tc.want {
+			t.Errorf("%s: position %s, want %s", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	src := "let a = 1\n" +
+		"print(a $ 2);\n" +
+		"while a < 3 {\n" +
+		"\ta = ;\n" +
+		"\tprint(a);\n" +
+		"}\n" +
+		"let b = a + 1;\n"
+
+	prog, pErr := parser.ParseFile("prog.lang", src)
+
+	wantErrs := []string{"prog.lang:1:10:", "prog.lang:2:9:", "prog.lang:4:6:"}
+	if len(pErr) < len(wantErrs) {
+		t.Fatalf("got %d errors, want at least %d: %v", len(pErr), len(wantErrs), pErr)
+	}
+	for _, want := range wantErrs {
+		found := false
+		for _, e := range pErr {
+			found = found || strings.HasPrefix(e, want)
+		}
+		if !found {
+			t.Errorf("no error at %s in %v", want, pErr)
+		}
+	}
+
+	// statements after the errors are still parsed
+	last, ok := prog.Body[len(prog.Body)-1].(ast.VarDeclarationStmt)
+	if !ok || last.Identifier != "b" {
+		t.Errorf("last statement %#v, want let b", prog.Body[len(prog.Body)-1])
+	}
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	prog, pErr := parser.Parse("let a: int = 1;\nlet b: long;\nlet s: string;\nlet buf: [byte; 64];\nlet u: uint;\n")
+	if len(pErr) != 0 {
+		t.Fatalf("parse errors: %v", pErr)
+	}
+
+	want := []ast.Stmt{
+		ast.VarDeclarationStmt{Identifier: "a", ExplicitType: ast.IntType, AssignedValue: ast.NumberExpr{Value: 1}},
+		ast.VarDeclarationStmt{Identifier: "b", ExplicitType: ast.LongType},
+		ast.VarDeclarationStmt{Identifier: "s", ExplicitType: ast.StringType},
+		ast.VarDeclarationStmt{Identifier: "buf", ExplicitType: ast.ArrayType{Element: ast.ByteType, Len: 64}},
+		ast.VarDeclarationStmt{Identifier: "u", ExplicitType: ast.UintType},
+	}
+	if diff := cmp.Diff(want, prog.Body, ignorePos); diff != "" {
+		t.Errorf("AST mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, src := range []string{"let a: float;", "let b: [byte; 0];", "let c: [string; 4];"} {
+		if _, pErr := parser.Parse(src); len(pErr) == 0 {
+			t.Errorf("%q: expected a parse error", src)
+		}
+	}
+}
@@ -2,10 +2,10 @@ package parser
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
 	"github.com/awesoma31/csa-lab4/pkg/translator/lexer"
-	"github.com/sanity-io/litter"
 )
 
 func parseStmt(p *parser) ast.Stmt {
@@ -18,34 +18,72 @@ func parseStmt(p *parser) ast.Stmt {
 	return parseExpressionStmt(p)
 }
 
+// parseStmtSync parses a statement, a statement with an error is dropped
+// and parsing resumes at the next statement boundary.
+func parseStmtSync(p *parser) ast.Stmt {
+	start := p.pos
+	stmt := parseStmt(p)
+	if p.panicking {
+		p.synchronize(start)
+		return nil
+	}
+	return stmt
+}
+
 func parseExpressionStmt(p *parser) ast.ExpressionStmt {
-	expression := parseExpr(p, defaultBp)
+	pos := p.currentPos()
+	expression := parseIncDec(p, parseExpr(p, defaultBp))
 	p.expect(lexer.SemiColon)
 
 	return ast.ExpressionStmt{
+		Node:       ast.Node{Pos: pos},
 		Expression: expression,
 	}
 }
 
+// parseIncDec turns a trailing ++/-- into an assignment.
+// i++ and i-- are statements, not expressions.
+func parseIncDec(p *parser, expression ast.Expr) ast.Expr {
+	if k := p.currentTokenKind(); k == lexer.PlusPlus || k == lexer.MinusMinus {
+		op := p.advance()
+		return ast.AssignmentExpr{
+			Node:          ast.Node{Pos: posOf(expression, op)},
+			Assigne:       expression,
+			Operator:      op,
+			AssignedValue: ast.NumberExpr{Node: ast.Node{Pos: op.Pos}, Value: 1},
+		}
+	}
+	return expression
+}
+
 func parseBlockStmt(p *parser) ast.Stmt {
-	p.expect(lexer.OpenCurly)
+	open := p.expect(lexer.OpenCurly)
 	var body []ast.Stmt
 
 	for p.hasTokens() && p.currentTokenKind() != lexer.CloseCurly {
-		body = append(body, parseStmt(p))
+		if stmt := parseStmtSync(p); stmt != nil {
+			body = append(body, stmt)
+		}
 	}
 
 	p.expect(lexer.CloseCurly)
 	return ast.BlockStmt{
+		Node: ast.Node{Pos: open.Pos},
 		Body: body,
 	}
 }
 
 func parseVarDeclStmt(p *parser) ast.Stmt {
-	startToken := p.advance().Kind
+	startToken := p.advance()
 	symbolName := p.expectError(lexer.IDENTIFIER,
 		fmt.Sprintf("Following %s expected variable name however instead recieved %s instead\n",
-			lexer.TokenKindString(startToken), lexer.TokenKindString(p.currentTokenKind())))
+			lexer.TokenKindString(startToken.Kind), lexer.TokenKindString(p.currentTokenKind())))
+
+	var explicitType ast.Type
+	if p.currentTokenKind() == lexer.COLON {
+		p.advance()
+		explicitType = parseType(p)
+	}
 
 	var assignmentValue ast.Expr
 	if p.currentTokenKind() != lexer.SemiColon {
@@ -56,13 +94,30 @@ func parseVarDeclStmt(p *parser) ast.Stmt {
 	p.expect(lexer.SemiColon)
 
 	return ast.VarDeclarationStmt{
+		Node:          ast.Node{Pos: startToken.Pos},
 		Identifier:    symbolName.Value,
 		AssignedValue: assignmentValue,
+		ExplicitType:  explicitType,
+	}
+}
+
+// parseConstDeclStmt parses `const N = v;`.
+func parseConstDeclStmt(p *parser) ast.Stmt {
+	tok := p.expect(lexer.CONST)
+	name := p.expect(lexer.IDENTIFIER)
+	p.expect(lexer.ASSIGNMENT)
+	value := parseExpr(p, assignment)
+	p.expect(lexer.SemiColon)
+
+	return ast.ConstDeclarationStmt{
+		Node:       ast.Node{Pos: tok.Pos},
+		Identifier: name.Value,
+		Value:      value,
 	}
 }
 
 func parsePrintStmt(p *parser) ast.Stmt {
-	p.expect(lexer.PRINT)
+	tok := p.expect(lexer.PRINT)
 	p.expect(lexer.OpenParen)
 
 	arg := parseExpr(p, defaultBp)
@@ -70,11 +125,21 @@ func parsePrintStmt(p *parser) ast.Stmt {
 	p.expect(lexer.CloseParen)
 	p.expect(lexer.SemiColon)
 
-	return ast.PrintStmt{Argument: arg}
+	return ast.PrintStmt{Node: ast.Node{Pos: tok.Pos}, Argument: arg}
+}
+
+func parseFreeStmt(p *parser) ast.Stmt {
+	tok := p.expect(lexer.FREE)
+	p.expect(lexer.OpenParen)
+	target := parseExpr(p, defaultBp)
+	p.expect(lexer.CloseParen)
+	p.expect(lexer.SemiColon)
+
+	return ast.FreeStmt{Node: ast.Node{Pos: tok.Pos}, Target: target}
 }
 
 func parseIfStmt(p *parser) ast.Stmt {
-	p.advance()
+	tok := p.advance()
 	condition := parseExpr(p, assignment)
 	consequent := parseBlockStmt(p)
 
@@ -90,41 +155,190 @@ func parseIfStmt(p *parser) ast.Stmt {
 	}
 
 	return ast.IfStmt{
+		Node:       ast.Node{Pos: tok.Pos},
 		Condition:  condition,
 		Consequent: consequent,
 		Alternate:  alternate,
 	}
 }
 
+// parseMatchStmt parses `match x { 1 => {...} 2, 3 => {...} _ => {...} }`.
+// Arm values are integer literals, `_` is the default arm.
+func parseMatchStmt(p *parser) ast.Stmt {
+	tok := p.expect(lexer.MATCH)
+	match := ast.MatchStmt{Node: ast.Node{Pos: tok.Pos}, Subject: parseExpr(p, assignment)}
+	p.expect(lexer.OpenCurly)
+
+	for p.hasTokens() && p.currentTokenKind() != lexer.CloseCurly && !p.panicking {
+		armPos := p.currentPos()
+		if p.currentTokenKind() == lexer.IDENTIFIER && p.currentToken().Value == "_" {
+			p.advance()
+			p.expect(lexer.FatArrow)
+			body := parseBlockStmt(p)
+			if match.Default != nil {
+				p.addErrorAt(armPos, "match has more than one default arm")
+			}
+			match.Default = body
+			continue
+		}
+
+		arm := ast.MatchArm{Node: ast.Node{Pos: armPos}}
+		for {
+			arm.Values = append(arm.Values, parseMatchValue(p))
+			if p.currentTokenKind() != lexer.COMMA {
+				break
+			}
+			p.advance()
+		}
+		p.expect(lexer.FatArrow)
+		arm.Body = parseBlockStmt(p).(ast.BlockStmt)
+		match.Arms = append(match.Arms, arm)
+	}
+
+	p.expect(lexer.CloseCurly)
+	return match
+}
+
+// parseMatchValue parses an arm value: an integer literal, optionally negative.
+func parseMatchValue(p *parser) int32 {
+	negative := p.currentTokenKind() == lexer.MINUS
+	if negative {
+		p.advance()
+	}
+	tok := p.expect(lexer.NUMBER)
+	if tok.Kind != lexer.NUMBER {
+		return 0
+	}
+	n, err := strconv.ParseInt(tok.Value, 10, 32)
+	if negative {
+		n = -n
+	}
+	if err != nil {
+		p.addErrorAt(tok.Pos, fmt.Sprintf("match value %s is not an int", tok.Value))
+	}
+	return int32(n)
+}
+
 func parseWhileStmt(p *parser) ast.Stmt {
-	p.expect(lexer.WHILE)
+	tok := p.expect(lexer.WHILE)
 	cond := parseExpr(p, assignment)
 	body := parseBlockStmt(p)
-	return ast.WhileStmt{Condition: cond, Body: body}
+	return ast.WhileStmt{Node: ast.Node{Pos: tok.Pos}, Condition: cond, Body: body}
+}
+
+// parseForStmt parses `for x in a..b {}`, `for x in arr {}` and `for init; cond; post {}`.
+func parseForStmt(p *parser) ast.Stmt {
+	tok := p.expect(lexer.FOR)
+	node := ast.Node{Pos: tok.Pos}
+
+	if p.currentTokenKind() == lexer.IDENTIFIER && p.peekKind() == lexer.IN {
+		value := p.advance().Value
+		p.expect(lexer.IN)
+
+		iterable := parseExpr(p, assignment)
+		if p.currentTokenKind() == lexer.DotDot {
+			p.advance()
+			iterable = ast.RangeExpr{Node: ast.Node{Pos: posOf(iterable, tok)}, Lower: iterable, Upper: parseExpr(p, assignment)}
+		}
+		body := parseBlockStmt(p).(ast.BlockStmt)
+		return ast.ForeachStmt{Node: node, Value: value, Iterable: iterable, Body: body.Body}
+	}
+
+	init := parseStmt(p)
+	cond := parseExpr(p, assignment)
+	p.expect(lexer.SemiColon)
+	post := parseIncDec(p, parseExpr(p, defaultBp))
+	body := parseBlockStmt(p)
+	return ast.ForStmt{Node: node, Init: init, Condition: cond, Post: post, Body: body}
+}
+
+func parseFnDeclStmt(p *parser) ast.Stmt {
+	tok := p.expect(lexer.FN)
+	name := p.expectError(lexer.IDENTIFIER,
+		fmt.Sprintf("Following fn expected function name however instead recieved %s instead\n",
+			lexer.TokenKindString(p.currentTokenKind())))
+
+	p.expect(lexer.OpenParen)
+	params := make([]ast.Parameter, 0)
+	for p.hasTokens() && p.currentTokenKind() != lexer.CloseParen && !p.panicking {
+		paramName := p.expect(lexer.IDENTIFIER)
+		params = append(params, ast.Parameter{Pos: paramName.Pos, Name: paramName.Value})
+
+		if p.currentTokenKind() != lexer.CloseParen {
+			p.expect(lexer.COMMA)
+		}
+	}
+	p.expect(lexer.CloseParen)
+
+	body := parseBlockStmt(p).(ast.BlockStmt)
+	return ast.FunctionDeclarationStmt{
+		Node:       ast.Node{Pos: tok.Pos},
+		Name:       name.Value,
+		Parameters: params,
+		Body:       body.Body,
+	}
+}
+
+func parseReturnStmt(p *parser) ast.Stmt {
+	tok := p.expect(lexer.RETURN)
+
+	var value ast.Expr
+	if p.currentTokenKind() != lexer.SemiColon {
+		value = parseExpr(p, defaultBp)
+	}
+	p.expect(lexer.SemiColon)
+
+	return ast.ReturnStmt{Node: ast.Node{Pos: tok.Pos}, Expr: value}
+}
+
+func parseBreakStmt(p *parser) ast.Stmt {
+	tok := p.expect(lexer.BREAK)
+	p.expect(lexer.SemiColon)
+	return ast.BreakStmt{Node: ast.Node{Pos: tok.Pos}}
+}
+
+func parseContinueStmt(p *parser) ast.Stmt {
+	tok := p.expect(lexer.CONTINUE)
+	p.expect(lexer.SemiColon)
+	return ast.ContinueStmt{Node: ast.Node{Pos: tok.Pos}}
 }
 
 func parseInterStmt(p *parser) ast.Stmt {
-	p.expect(lexer.INTER)
+	tok := p.expect(lexer.INTER)
+	n := parseExpr(p, assignment)
+	inter := ast.InterruptionStmt{Node: ast.Node{Pos: tok.Pos}}
+	if a, ok := n.(ast.NumberExpr); ok {
+		inter.IrqNumber = int(a.Value)
+	} else {
+		// a constant, checked and evaluated by sema
+		inter.IrqExpr = n
+	}
+
+	inter.Body = parseBlockStmt(p)
+	return inter
+}
+func parseTrapStmt(p *parser) ast.Stmt {
+	tok := p.expect(lexer.TRAP)
 	n := parseExpr(p, assignment)
-	var irqN int
-	switch a := n.(type) {
-	case ast.NumberExpr:
-		irqN = int(a.Value)
-	default:
-		p.addError(fmt.Sprint("interruption number must be a number, got: ", litter.Sdump(n)))
+	trap := ast.TrapStmt{Node: ast.Node{Pos: tok.Pos}}
+	if a, ok := n.(ast.NumberExpr); ok {
+		trap.Cause = int(a.Value)
+	} else {
+		// a constant, checked and evaluated by sema
+		trap.CauseExpr = n
 	}
 
-	b := parseBlockStmt(p)
-	return ast.InterruptionStmt{IrqNumber: irqN, Body: b}
+	trap.Body = parseBlockStmt(p)
+	return trap
 }
 func parseIntOnStmt(p *parser) ast.Stmt {
-	p.expect(lexer.IntOn)
+	tok := p.expect(lexer.IntOn)
 	p.expect(lexer.SemiColon)
-	return ast.IntOnStmt{}
+	return ast.IntOnStmt{Node: ast.Node{Pos: tok.Pos}}
 }
 
 func parseIntOffStmt(p *parser) ast.Stmt {
-	p.expect(lexer.IntOff)
+	tok := p.expect(lexer.IntOff)
 	p.expect(lexer.SemiColon)
-	return ast.IntOffStmt{}
+	return ast.IntOffStmt{Node: ast.Node{Pos: tok.Pos}}
 }
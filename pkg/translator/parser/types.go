@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
+	"github.com/awesoma31/csa-lab4/pkg/translator/lexer"
+)
+
+type typeNudHandler func(p *parser) ast.Type
+
+type typeNudLookup map[lexer.TokenKind]typeNudHandler
+
+var typeNudLu = typeNudLookup{}
+
+func typeNud(kind lexer.TokenKind, fn typeNudHandler) {
+	typeNudLu[kind] = fn
+}
+
+// createTypeTokenLookups initializes the lookup table of type annotations.
+func createTypeTokenLookups() {
+	typeNud(lexer.IDENTIFIER, parseSymbolType)
+	typeNud(lexer.OpenBracket, parseArrayType)
+}
+
+// scalarTypes are the types a variable can be annotated with by name.
+var scalarTypes = map[string]ast.Type{
+	"int":    ast.IntType,
+	"uint":   ast.UintType,
+	"long":   ast.LongType,
+	"string": ast.StringType,
+	"bool":   ast.BoolType,
+}
+
+// arrayElementTypes are the element types of `[T; N]` arrays.
+var arrayElementTypes = map[string]ast.Type{
+	"byte": ast.ByteType,
+	"int":  ast.IntType,
+	"long": ast.LongType,
+}
+
+// parseType parses a type annotation: `int`, `uint`, `long`, `string`, `bool`, `[T; N]` or `[T]`.
+func parseType(p *parser) ast.Type {
+	typeFn, exists := typeNudLu[p.currentTokenKind()]
+	if !exists {
+		p.addError(fmt.Sprintf("Expected a type but got %s", lexer.TokenKindString(p.currentTokenKind())))
+		return nil
+	}
+	return typeFn(p)
+}
+
+func parseSymbolType(p *parser) ast.Type {
+	name := p.advance()
+	typ, known := scalarTypes[name.Value]
+	if !known {
+		p.addErrorAt(name.Pos, fmt.Sprintf("Unknown type %s", name.Value))
+		return nil
+	}
+	return typ
+}
+
+// parseArrayType parses `[T; N]`, T is byte, int or long, N is a positive number literal.
+// `[T]` without a length is an array whose size is known only at runtime, e.g. one
+// returned by alloc.
+func parseArrayType(p *parser) ast.Type {
+	p.expect(lexer.OpenBracket)
+	elemTok := p.expect(lexer.IDENTIFIER)
+	elem, known := arrayElementTypes[elemTok.Value]
+	if !known && elemTok.Kind == lexer.IDENTIFIER {
+		p.addErrorAt(elemTok.Pos, fmt.Sprintf("Unsupported array element type %s", elemTok.Value))
+	}
+	if p.currentTokenKind() == lexer.CloseBracket {
+		p.advance()
+		return ast.ListType{Underlying: elem}
+	}
+	p.expect(lexer.SemiColon)
+	lenTok := p.expect(lexer.NUMBER)
+	n, err := strconv.Atoi(lenTok.Value)
+	if lenTok.Kind == lexer.NUMBER && (err != nil || n <= 0) {
+		p.addErrorAt(lenTok.Pos, fmt.Sprintf("Array length must be a positive number, got %s", lenTok.Value))
+	}
+	p.expect(lexer.CloseBracket)
+	return ast.ArrayType{Element: elem, Len: n}
+}
@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	bingen "github.com/awesoma31/csa-lab4/pkg/bin-gen"
 	"github.com/awesoma31/csa-lab4/pkg/logutil"
 	"github.com/awesoma31/csa-lab4/pkg/translator/codegen"
+	"github.com/awesoma31/csa-lab4/pkg/translator/diag"
 	"github.com/awesoma31/csa-lab4/pkg/translator/parser"
+	"github.com/awesoma31/csa-lab4/pkg/translator/sema"
 )
 
 type Options struct {
@@ -16,6 +19,8 @@ type Options struct {
 	OutDir  string
 	LogDir  string
 	Debug   bool
+
+	Codegen codegen.Options
 }
 
 func Run(opts Options) (imem []uint32, dmem []byte, err error) {
@@ -23,15 +28,23 @@ func Run(opts Options) (imem []uint32, dmem []byte, err error) {
 	if err != nil {
 		return nil, nil, fmt.Errorf("read src: %w", err)
 	}
-	ast, pErr := parser.Parse(string(src))
+	ast, pErr := parser.ParseFile(opts.SrcPath, string(src))
 	if len(pErr) != 0 {
-		return nil, nil, fmt.Errorf("parse: %v", pErr)
+		return nil, nil, fmt.Errorf("parse:\n%s", strings.Join(pErr, "\n"))
+	}
+
+	ast, semErr := sema.Check(ast)
+	if len(semErr) != 0 {
+		source := diag.Source{File: opts.SrcPath, Text: string(src)}
+		return nil, nil, fmt.Errorf("semantic:\n%s", strings.Join(source.FormatAll(semErr), "\n"))
 	}
 
 	cg := codegen.NewCodeGenerator()
+	cg.SetSource(opts.SrcPath, string(src))
+	cg.SetOptions(opts.Codegen)
 	imem, dmem, dbgAsm, cgErr := cg.Generate(ast)
 	if len(cgErr) != 0 {
-		return nil, nil, fmt.Errorf("codegen: %v", cgErr)
+		return nil, nil, fmt.Errorf("codegen:\n%s", strings.Join(cgErr, "\n"))
 	}
 
 	if err := os.MkdirAll(opts.OutDir, 0o755); err != nil {
@@ -0,0 +1,123 @@
+package sema
+
+import (
+	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
+	"github.com/awesoma31/csa-lab4/pkg/translator/lexer"
+)
+
+// Constants
+//
+// `const N = v;` is evaluated here and never reaches the code generator as a
+// variable: every use of N is replaced with the number literal, so N takes
+// no data memory. v may use number literals, true/false, other constants and
+// the int operators; the result wraps around like on the machine.
+
+// constDecl checks `const N = v;` and replaces v with its value.
+func (c *checker) constDecl(s ast.ConstDeclarationStmt) ast.Stmt {
+	v, _ := c.constant(s.Value, "value of constant '"+s.Identifier+"'")
+	s.Value = ast.NumberExpr{Node: ast.Node{Pos: s.Value.Position(), Type: ast.IntType}, Value: v}
+
+	current := c.scopes[len(c.scopes)-1]
+	if _, found := current[s.Identifier]; found {
+		c.addError("Variable '%s' already declared in this scope", s.Identifier)
+		return s
+	}
+	current[s.Identifier] = symbol{typ: ast.IntType, constant: true, value: v}
+	return s
+}
+
+// constant evaluates expr at compile time, what names the value in errors.
+// It reports false if expr is not a constant expression.
+func (c *checker) constant(expr ast.Expr, what string) (int32, bool) {
+	switch e := expr.(type) {
+	case ast.NumberExpr:
+		return e.Value, true
+	case ast.BoolExpr:
+		if e.Value {
+			return 1, true
+		}
+		return 0, true
+	case ast.SymbolExpr:
+		if sym, found := c.lookup(e.Value); found && sym.constant {
+			return sym.value, true
+		}
+	case ast.PrefixExpr:
+		v, ok := c.constant(e.Right, what)
+		if !ok {
+			return 0, false
+		}
+		switch e.Operator.Kind {
+		case lexer.MINUS:
+			return -v, true
+		case lexer.PLUS:
+			return v, true
+		case lexer.TILDE:
+			return ^v, true
+		case lexer.NOT:
+			if v == 0 {
+				return 1, true
+			}
+			return 0, true
+		}
+	case ast.BinaryExpr:
+		a, ok := c.constant(e.Left, what)
+		if !ok {
+			return 0, false
+		}
+		b, ok := c.constant(e.Right, what)
+		if !ok {
+			return 0, false
+		}
+		return c.constantBinary(e, a, b)
+	}
+	c.errorAt(expr, "%s must be a constant expression", what)
+	return 0, false
+}
+
+// longLiteral returns the value of a long literal, optionally negated.
+func longLiteral(expr ast.Expr) (int64, bool) {
+	switch e := expr.(type) {
+	case ast.LongNumberExpr:
+		return e.Value, true
+	case ast.PrefixExpr:
+		if lit, ok := e.Right.(ast.LongNumberExpr); ok && e.Operator.Kind == lexer.MINUS {
+			return -lit.Value, true
+		}
+	}
+	return 0, false
+}
+
+// constantBinary applies the operator of e to constant operands.
+func (c *checker) constantBinary(e ast.BinaryExpr, a, b int32) (int32, bool) {
+	switch e.Operator.Kind {
+	case lexer.PLUS:
+		return a + b, true
+	case lexer.MINUS:
+		return a - b, true
+	case lexer.STAR:
+		return a * b, true
+	case lexer.SLASH, lexer.PERCENT:
+		if b == 0 {
+			c.errorAt(e, "division by zero in constant expression")
+			return 0, false
+		}
+		if e.Operator.Kind == lexer.SLASH {
+			return a / b, true
+		}
+		return a % b, true
+	case lexer.AMPERSAND:
+		return a & b, true
+	case lexer.PIPE:
+		return a | b, true
+	case lexer.CARET:
+		return a ^ b, true
+	case lexer.ShiftLeft:
+		return a << (uint32(b) % 32), true
+	case lexer.ShiftRight:
+		return a >> (uint32(b) % 32), true
+	case lexer.ShiftRightUnsigned:
+		return int32(uint32(a) >> (uint32(b) % 32)), true
+	}
+	c.errorAt(e, "operator %s is not allowed in constant expressions", e.Operator.Value)
+	return 0, false
+}
@@ -0,0 +1,340 @@
+package sema
+
+import (
+	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
+	"github.com/awesoma31/csa-lab4/pkg/translator/lexer"
+)
+
+// expr checks an expression and returns a copy annotated with its type.
+// When the type cannot be inferred because of an error it is left nil.
+func (c *checker) expr(expr ast.Expr) ast.Expr {
+	if expr == nil {
+		return nil
+	}
+	defer c.enter(expr)()
+	switch e := expr.(type) {
+	case ast.NumberExpr:
+		e.Type = ast.IntType
+		return e
+	case ast.LongNumberExpr:
+		e.Type = ast.LongType
+		return e
+	case ast.StringExpr:
+		e.Type = ast.StringType
+		return e
+	case ast.BoolExpr:
+		e.Type = ast.BoolType
+		return e
+	case ast.ReadIntExpr:
+		e.Type = ast.IntType
+		return e
+	case ast.ReadLongExpr:
+		e.Type = ast.LongType
+		return e
+	case ast.TrapCauseExpr:
+		e.Type = ast.IntType
+		return e
+	case ast.ReadChExpr:
+		e.Type = ast.StringType
+		return e
+	case ast.ListEx:
+		if e.SizeExpr != nil {
+			size, _ := c.constant(e.SizeExpr, "list size")
+			if size <= 0 {
+				c.errorAt(e.SizeExpr, "list size must be positive, got %d", size)
+			}
+			e.Size, e.SizeExpr = int(size), nil
+		}
+		e.Type = ast.ByteListType
+		return e
+	case ast.AllocExpr:
+		// a list of bytes unless stored into a [int] or [long], see allocOf
+		e.Size = c.word(e.Size, "alloc size")
+		e.Type = ast.ByteListType
+		return e
+	case ast.SymbolExpr:
+		sym, found := c.lookup(e.Value)
+		if !found {
+			c.addError("Undeclared variable '%s'", e.Value)
+			return e
+		}
+		if sym.constant {
+			return ast.NumberExpr{Node: ast.Node{Pos: e.Pos, Type: ast.IntType}, Value: sym.value}
+		}
+		e.Type = sym.typ
+		return e
+	case ast.ArrayIndexEx:
+		e.Target = c.expr(e.Target)
+		e.Index = c.word(e.Index, "index")
+		if t := e.Target.StaticType(); t != nil && ast.KindOf(t) != ast.TypeList {
+			c.addError("cannot index %s", typeName(t))
+		}
+		e.Type = elementType(e.Target.StaticType())
+		return e
+	case ast.ArrayLiteral:
+		return c.arrayLiteral(e)
+	case ast.BinaryExpr:
+		return c.binary(e)
+	case ast.PrefixExpr:
+		if e.Operator.Kind == lexer.NOT {
+			e.Right = c.condition(e.Right)
+			e.Type = ast.BoolType
+			return e
+		}
+		e.Right = c.operand(e.Right, e.Operator)
+		e.Type = ast.IntType
+		if isLong(e.Right) {
+			e.Type = ast.LongType
+		} else if isUnsigned(e.Right) {
+			e.Type = ast.UintType
+		}
+		return e
+	case ast.AssignmentExpr:
+		return c.assignment(e)
+	case ast.TernaryExpr:
+		return c.ternary(e)
+	case ast.CallExpr:
+		return c.call(e)
+	default:
+		return expr
+	}
+}
+
+// binaryResults maps operators that do not produce an int to their result type.
+var binaryResults = map[lexer.TokenKind]ast.Type{
+	lexer.EQUALS:        ast.BoolType,
+	lexer.NotEquals:     ast.BoolType,
+	lexer.LESS:          ast.BoolType,
+	lexer.LessEquals:    ast.BoolType,
+	lexer.GREATER:       ast.BoolType,
+	lexer.GreaterEquals: ast.BoolType,
+	lexer.AND:           ast.BoolType,
+	lexer.OR:            ast.BoolType,
+}
+
+// longOperators are the operators also defined for long operands, the others take words only.
+var longOperators = map[lexer.TokenKind]bool{
+	lexer.PLUS:          true,
+	lexer.MINUS:         true,
+	lexer.STAR:          true,
+	lexer.SLASH:         true,
+	lexer.PERCENT:       true,
+	lexer.EQUALS:        true,
+	lexer.NotEquals:     true,
+	lexer.LESS:          true,
+	lexer.LessEquals:    true,
+	lexer.GREATER:       true,
+	lexer.GreaterEquals: true,
+}
+
+// binary checks a binary expression. Operators are defined on int and bool operands,
+// arithmetic and comparisons also on longs: an int operand is widened then,
+// arithmetic gives a long. With a uint operand arithmetic gives a uint and
+// comparisons are unsigned.
+func (c *checker) binary(e ast.BinaryExpr) ast.Expr {
+	if e.Operator.Kind == lexer.AND || e.Operator.Kind == lexer.OR {
+		e.Left = c.condition(e.Left)
+		e.Right = c.condition(e.Right)
+	} else {
+		e.Left = c.operand(e.Left, e.Operator)
+		e.Right = c.operand(e.Right, e.Operator)
+	}
+	e.Type = ast.IntType
+	if isLong(e.Left) || isLong(e.Right) {
+		e.Type = ast.LongType
+	} else if isUnsigned(e.Left) || isUnsigned(e.Right) {
+		e.Type = ast.UintType
+	}
+	if t, ok := binaryResults[e.Operator.Kind]; ok {
+		e.Type = t
+	}
+	return e
+}
+
+// operand checks an operand of an arithmetic, bitwise or relational operator.
+func (c *checker) operand(expr ast.Expr, op lexer.Token) ast.Expr {
+	expr = c.expr(expr)
+	if expr != nil && !isWord(expr.StaticType()) && !(longOperators[op.Kind] && isLong(expr)) {
+		c.errorAt(expr, "operator %s is not defined for %s", op.Value, typeName(expr.StaticType()))
+	}
+	return expr
+}
+
+// condition checks an expression used as a condition, non-zero ints are true.
+func (c *checker) condition(expr ast.Expr) ast.Expr {
+	return c.word(expr, "condition")
+}
+
+// word checks an expression whose value must be an int or a bool, what names its role in errors.
+func (c *checker) word(expr ast.Expr, what string) ast.Expr {
+	expr = c.expr(expr)
+	if expr != nil && !isWord(expr.StaticType()) {
+		c.errorAt(expr, "%s must be int or bool, got %s", what, typeName(expr.StaticType()))
+	}
+	return expr
+}
+
+// ternary checks `cond ? a : b`. The branches must have the same type, except
+// that words mix like operands of arithmetic and a word widens to a long.
+func (c *checker) ternary(e ast.TernaryExpr) ast.Expr {
+	e.Condition = c.condition(e.Condition)
+	e.Consequent = c.expr(e.Consequent)
+	e.Alternate = c.expr(e.Alternate)
+
+	a, b := e.Consequent.StaticType(), e.Alternate.StaticType()
+	switch {
+	case a == nil || b == nil:
+	case isLong(e.Consequent) || isLong(e.Alternate):
+		if !assignable(ast.LongType, a) || !assignable(ast.LongType, b) {
+			break
+		}
+		e.Type = ast.LongType
+		return e
+	case ast.KindOf(a) == ast.TypeBool && ast.KindOf(b) == ast.TypeBool:
+		e.Type = ast.BoolType
+		return e
+	case isUnsigned(e.Consequent) || isUnsigned(e.Alternate):
+		if !isWord(a) || !isWord(b) {
+			break
+		}
+		e.Type = ast.UintType
+		return e
+	case isWord(a) && isWord(b):
+		e.Type = ast.IntType
+		return e
+	case ast.KindOf(a) == ast.KindOf(b):
+		e.Type = a
+		return e
+	}
+	if a != nil && b != nil {
+		c.addError("branches of ?: have different types %s and %s", typeName(a), typeName(b))
+	}
+	return e
+}
+
+func (c *checker) assignment(e ast.AssignmentExpr) ast.Expr {
+	if target, ok := e.Assigne.(ast.SymbolExpr); ok {
+		if sym, _ := c.lookup(target.Value); sym.constant {
+			c.addError("cannot assign to constant '%s'", target.Value)
+			return e
+		}
+	}
+	switch e.Assigne.(type) {
+	case ast.SymbolExpr, ast.ArrayIndexEx:
+		e.Assigne = c.expr(e.Assigne)
+	default:
+		c.addError("cannot assign to %T", e.Assigne)
+		return e
+	}
+	target := e.Assigne.StaticType()
+
+	if e.Operator.Kind != lexer.ASSIGNMENT {
+		// compound assignment, ++ and --, a long target takes int and long operands
+		if !isNumber(target) {
+			c.addError("operator %s is not defined for %s", e.Operator.Value, typeName(target))
+		}
+		e.AssignedValue = c.expr(e.AssignedValue)
+		if value := e.AssignedValue.StaticType(); !isNumber(value) || !assignable(target, value) {
+			c.errorAt(e.AssignedValue, "operator %s is not defined for %s", e.Operator.Value, typeName(value))
+		}
+		e.Type = target
+		return e
+	}
+
+	e.AssignedValue = allocOf(target, uintLiteral(target, c.expr(e.AssignedValue)))
+	if value := e.AssignedValue.StaticType(); !assignable(target, value) {
+		c.addError("cannot assign %s to %s", typeName(value), typeName(target))
+	}
+	e.Type = target
+	return e
+}
+
+// elementType returns the type of an element of list as a value: a long or an int.
+func elementType(list ast.Type) ast.Type {
+	if ast.KindOf(ast.ElementOf(list)) == ast.TypeLong {
+		return ast.LongType
+	}
+	return ast.IntType
+}
+
+// arrayLiteral checks `[a, b, c]`, the elements are constants placed in data memory.
+// The literal is a list of ints, or of longs if any element is a long literal.
+func (c *checker) arrayLiteral(e ast.ArrayLiteral) ast.Expr {
+	if len(e.Contents) == 0 {
+		c.addError("array literal must have at least one element")
+	}
+	long := false
+	values := make([]int64, len(e.Contents))
+	for i, elem := range e.Contents {
+		if v, ok := longLiteral(elem); ok {
+			values[i], long = v, true
+			continue
+		}
+		v, _ := c.constant(elem, "array element")
+		values[i] = int64(v)
+	}
+
+	contents := make([]ast.Expr, len(values))
+	for i, v := range values {
+		node := ast.Node{Pos: e.Contents[i].Position(), Type: ast.IntType}
+		if long {
+			node.Type = ast.LongType
+			contents[i] = ast.LongNumberExpr{Node: node, Value: v}
+		} else {
+			contents[i] = ast.NumberExpr{Node: node, Value: int32(v)}
+		}
+	}
+	e.Contents = contents
+	e.Type = ast.IntListType
+	if long {
+		e.Type = ast.LongListType
+	}
+	return e
+}
+
+// isLong reports whether expr is a long value.
+func isLong(expr ast.Expr) bool {
+	return expr != nil && ast.KindOf(expr.StaticType()) == ast.TypeLong
+}
+
+// isUnsigned reports whether expr is a uint value.
+func isUnsigned(expr ast.Expr) bool {
+	return expr != nil && ast.KindOf(expr.StaticType()) == ast.TypeUint
+}
+
+// builtins maps built-in functions to their argument and result type.
+var builtins = map[string]ast.Type{
+	lexer.TokenKindString(lexer.ADDSTR): ast.StringType,
+	lexer.TokenKindString(lexer.ADDL):   ast.LongType,
+}
+
+func (c *checker) call(e ast.CallExpr) ast.Expr {
+	args := make([]ast.Expr, len(e.Args))
+	if typ, builtin := builtins[e.Name]; builtin {
+		if len(e.Args) != 2 {
+			c.addError("%s( , ) must have 2 arguments, got %d", e.Name, len(e.Args))
+		}
+		for i, arg := range e.Args {
+			args[i] = c.expr(arg)
+			if t := args[i].StaticType(); t != nil && !assignable(typ, t) {
+				c.errorAt(args[i], "%s( , ) expects %s arguments, got %s", e.Name, typeName(typ), typeName(t))
+			}
+		}
+		e.Args = args
+		e.Type = typ
+		return e
+	}
+
+	fn, found := c.functions[e.Name]
+	if !found {
+		c.addError("unknown func name %s", e.Name)
+	} else if len(e.Args) != len(fn.Parameters) {
+		c.addError("function '%s' expects %d arguments, got %d", e.Name, len(fn.Parameters), len(e.Args))
+	}
+	for i, arg := range e.Args {
+		args[i] = c.word(arg, "argument")
+	}
+	e.Args = args
+	e.Type = ast.IntType
+	return e
+}
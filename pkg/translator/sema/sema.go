@@ -0,0 +1,190 @@
+// Package sema is the semantic pass between parsing and code generation.
+//
+// It resolves every symbol, infers static types and rejects invalid mixes
+// such as `"a" * 3` or indexing an int. The checked program is a copy of the
+// input in which every expression carries its type (ast.Node.Type), so code
+// generation does not have to guess it.
+//
+// Types:
+//
+//	int     number literals, arithmetic, readInt(), list elements, function results
+//	uint    variables annotated `uint`, arithmetic with a uint operand; compares,
+//	        divides and widens to long as unsigned, converts to and from int implicitly
+//	bool    comparisons, &&, ||, !; converts to int implicitly (0 or 1)
+//	long    long literals, readLong(), addL(a, b), + - * / % with a long operand;
+//	        ints and bools widen to long implicitly
+//	string  string literals, read(), addStr(a, b)
+//	list    list(n) and [byte; N] of bytes, array literals and [int; N] of ints,
+//	        [long; N] and literals with a long element of longs; alloc(n) of the
+//	        elements of the [T] it is stored into, of bytes otherwise; an element
+//	        is read as an int or a long
+//
+// Constants are ints evaluated here, see const.go.
+package sema
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
+	"github.com/awesoma31/csa-lab4/pkg/translator/diag"
+	"github.com/awesoma31/csa-lab4/pkg/translator/lexer"
+)
+
+type symbol struct {
+	typ      ast.Type // nil if the type could not be inferred, uses are not checked then
+	constant bool     // declared with const, uses are replaced with value
+	value    int32
+}
+
+type scope map[string]symbol
+
+type checker struct {
+	scopes    []scope
+	functions map[string]ast.FunctionDeclarationStmt
+	inFn      bool
+	pos       lexer.Pos // position of the node being checked
+	errors    []diag.Error
+}
+
+// Check checks program and returns it annotated with static types.
+// Function bodies are checked after the main program, as they are generated
+// after it and see every global variable.
+func Check(program ast.BlockStmt) (ast.BlockStmt, []diag.Error) {
+	c := &checker{functions: make(map[string]ast.FunctionDeclarationStmt)}
+	c.pushScope()
+
+	for _, stmt := range program.Body {
+		if fn, ok := stmt.(ast.FunctionDeclarationStmt); ok {
+			c.declareFunction(fn)
+		}
+	}
+
+	body := make([]ast.Stmt, len(program.Body))
+	for i, stmt := range program.Body {
+		if _, ok := stmt.(ast.FunctionDeclarationStmt); !ok {
+			body[i] = c.stmt(stmt)
+		}
+	}
+	for i, stmt := range program.Body {
+		if fn, ok := stmt.(ast.FunctionDeclarationStmt); ok {
+			body[i] = c.function(fn)
+		}
+	}
+
+	program.Body = body
+	return program, c.errors
+}
+
+func (c *checker) addError(format string, args ...any) {
+	c.errors = append(c.errors, diag.Error{Pos: c.pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+// errorAt records an error at the position of node.
+func (c *checker) errorAt(node interface{ Position() lexer.Pos }, format string, args ...any) {
+	defer c.enter(node)()
+	c.addError(format, args...)
+}
+
+// enter makes node the position of errors until the returned function is called.
+func (c *checker) enter(node interface{ Position() lexer.Pos }) func() {
+	saved := c.pos
+	if node != nil && node.Position().IsValid() {
+		c.pos = node.Position()
+	}
+	return func() { c.pos = saved }
+}
+
+// --- Scopes ---
+
+func (c *checker) pushScope() {
+	c.scopes = append(c.scopes, make(scope))
+}
+
+func (c *checker) popScope() {
+	c.scopes = c.scopes[:len(c.scopes)-1]
+}
+
+func (c *checker) declare(name string, typ ast.Type) {
+	current := c.scopes[len(c.scopes)-1]
+	if _, found := current[name]; found {
+		c.addError("Variable '%s' already declared in this scope", name)
+		return
+	}
+	current[name] = symbol{typ: typ}
+}
+
+func (c *checker) lookup(name string) (symbol, bool) {
+	for i := len(c.scopes) - 1; i >= 0; i-- {
+		if sym, found := c.scopes[i][name]; found {
+			return sym, true
+		}
+	}
+	return symbol{}, false
+}
+
+// --- Type helpers ---
+
+// isWord reports whether values of t are numbers held in a single word: int, uint and bool.
+// An unknown type is accepted, its error has already been reported.
+func isWord(t ast.Type) bool {
+	switch ast.KindOf(t) {
+	case ast.TypeUnknown, ast.TypeInt, ast.TypeUint, ast.TypeBool:
+		return true
+	default:
+		return false
+	}
+}
+
+// isNumber reports whether t is a word or a long, the operand types of arithmetic.
+func isNumber(t ast.Type) bool {
+	return isWord(t) || ast.KindOf(t) == ast.TypeLong
+}
+
+// assignable reports whether a value of type src can be stored into a variable of type dst.
+func assignable(dst, src ast.Type) bool {
+	if dst == nil || src == nil {
+		return true
+	}
+	if isWord(dst) && isWord(src) {
+		return true
+	}
+	if ast.KindOf(dst) == ast.TypeLong && isWord(src) {
+		return true
+	}
+	if ast.KindOf(dst) == ast.TypeList && ast.KindOf(src) == ast.TypeList {
+		// elements of a different size would be indexed wrong
+		return ast.KindOf(ast.ElementOf(dst)) == ast.KindOf(ast.ElementOf(src))
+	}
+	return ast.KindOf(dst) == ast.KindOf(src)
+}
+
+// uintLiteral converts a number literal too big for an int to a uint when it is
+// stored into a uint, so that `let x: uint = 4000000000;` does not need a long.
+func uintLiteral(dst ast.Type, expr ast.Expr) ast.Expr {
+	lit, ok := expr.(ast.LongNumberExpr)
+	if !ok || ast.KindOf(dst) != ast.TypeUint || lit.Value < 0 || lit.Value > math.MaxUint32 {
+		return expr
+	}
+	n := ast.NumberExpr{Node: lit.Node, Value: int32(uint32(lit.Value))}
+	n.Type = ast.UintType
+	return n
+}
+
+// allocOf gives alloc(n) the element type of the list it is stored into, so that
+// `let a: [int] = alloc(n);` takes n ints rather than n bytes.
+func allocOf(dst ast.Type, expr ast.Expr) ast.Expr {
+	alloc, ok := expr.(ast.AllocExpr)
+	if !ok || ast.KindOf(dst) != ast.TypeList {
+		return expr
+	}
+	alloc.Type = ast.ListType{Underlying: ast.ElementOf(dst)}
+	return alloc
+}
+
+func typeName(t ast.Type) string {
+	if t == nil {
+		return "unknown"
+	}
+	return t.String()
+}
@@ -0,0 +1,169 @@
+package sema_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
+	"github.com/awesoma31/csa-lab4/pkg/translator/parser"
+	"github.com/awesoma31/csa-lab4/pkg/translator/sema"
+)
+
+func check(t *testing.T, src string) (ast.BlockStmt, []string) {
+	t.Helper()
+	prog, pErr := parser.Parse(src)
+	if len(pErr) != 0 {
+		t.Fatalf("parse errors: %v", pErr)
+	}
+	checked, errs := sema.Check(prog)
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Pos.String() + ": " + e.Msg
+	}
+	return checked, msgs
+}
+
+func TestRejectsInvalidMixes(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"string arithmetic", `let a = "a" * 3;`, `1:9: operator * is not defined for string`},
+		{"index int", "let a = 5;\nlet b = a[0];", `2:9: cannot index int`},
+		{"index string", "let s = \"abc\";\nlet c = s[1];", `2:9: cannot index string`},
+		{"list condition", "let l = list(2);\nwhile l {}", `2:7: condition must be int or bool, got []byte`},
+		{"assign string to int", "let a = 1;\na = \"x\";", `2:1: cannot assign string to int`},
+		{"compound on string", "let s = \"x\";\ns += 1;", `2:1: operator += is not defined for string`},
+		{"undeclared", "print(y);", `1:7: Undeclared variable 'y'`},
+		{"redeclared", "let a = 1;\nlet a = 2;", `2:1: Variable 'a' already declared in this scope`},
+		{"string argument", "fn f(x) { return x; }\nprint(f(\"a\"));", `2:9: argument must be int or bool, got string`},
+		{"unknown function", "print(g(1));", `1:7: unknown func name g`},
+		{"string compare", "let s = \"a\";\nif s == \"a\" {}", `2:4: operator == is not defined for string`},
+		{"foreach over int", "for x in 5 {}", `1:10: cannot iterate over int`},
+		{"print list", "let l = list(1);\nprint(l);", `2:7: cannot print []byte`},
+		{"uninitialized", "let a;", `1:1: variable 'a' must be initialized`},
+		{"annotation mismatch", `let a: int = "x";`, `1:1: cannot use string as int in declaration of 'a'`},
+		{"array initializer", "let b: [byte; 4] = list(4);", `1:1: array 'b' cannot have an initializer`},
+		{"bitwise long", "let l: long = 1;\nlet b = l & 1;", `2:9: operator & is not defined for long`},
+		{"narrow long", "let i = 1;\nlet l: long = 1;\ni = l;", `3:1: cannot assign long to int`},
+		{"compound narrow", "let i = 1;\nlet l: long = 1;\ni += l;", `3:6: operator += is not defined for long`},
+		{"annotated use", "let s: string;\nlet n = s + 1;", `2:9: operator + is not defined for string`},
+		{"ternary branches", "let a = 1 ? \"x\" : 2;", `1:9: branches of ?: have different types string and int`},
+		{"ternary condition", "let a = \"x\" ? 1 : 2;", `1:9: condition must be int or bool, got string`},
+		{"uint overflow", "let u: uint = 4294967296;", `1:1: cannot use long as uint in declaration of 'u'`},
+		{"match duplicate", "let x = 1;\nmatch x { 1, 2 => { } 2 => { } }", `2:23: duplicate match value 2`},
+		{"match subject", "let s = \"x\";\nmatch s { 1 => { } }", `2:7: match value must be`},
+		{"assign constant", "const N = 1;\nN = 2;", `2:1: cannot assign to constant 'N'`},
+		{"increment constant", "const N = 1;\nN++;", `2:1: cannot assign to constant 'N'`},
+		{"variable in constant", "let n = 1;\nconst N = n + 1;", `2:11: value of constant 'N' must be a constant expression`},
+		{"list size", "let n = 4;\nlet l = list(n);", `2:14: list size must be a constant expression`},
+		{"constant division", "const N = 1 / (2 - 2);", `1:11: division by zero in constant expression`},
+		{"empty array literal", "let a = [];", `1:9: array literal must have at least one element`},
+		{"array element", "let n = 1;\nlet a = [1, n];", `2:13: array element must be a constant expression`},
+		{"array element size", "let a = [1, 2];\na = list(2);", `2:1: cannot assign []byte to []int`},
+		{"uint negative literal", "let u: uint = 1;\nu = -4294967295;", `2:1: cannot assign long to uint`},
+		{"alloc size", "let s = \"x\";\nlet a = alloc(s);", `2:15: alloc size must be int or bool, got string`},
+		{"free int", "let n = 1;\nfree(n);", `2:6: cannot free int`},
+		{"unsized uninitialized", "let a: [int];", `1:1: variable 'a' must be initialized`},
+		{"alloc element size", "let a: [int] = alloc(2);\nlet b: [long] = a;", `2:1: cannot use []int as []long in declaration of 'b'`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, errs := check(t, tc.src)
+			if len(errs) == 0 || !strings.Contains(strings.Join(errs, "\n"), tc.want) {
+				t.Errorf("errors %q, want %q", errs, tc.want)
+			}
+		})
+	}
+}
+
+func TestAnnotatesTypes(t *testing.T) {
+	prog, errs := check(t, `let s = "hi";
+let l = list(4);
+let n = l[1] + 2;
+let b = n < 3 && !n;
+let t = s;
+fn f(x) { return x * 2; }
+let r = f(n);
+let big = readLong();
+let w = big * n;
+let c = -w < big;
+let u: uint = 4000000000;
+let v = u + n;
+let z: long = u;
+let m = n > 0 ? big : n;
+let e = n > 0 ? s : "x";
+let done = false;
+let o = done || true;
+let ints = [1, 2, 3];
+let i0 = ints[0];
+let longs = [1, 5000000000];
+let l0 = longs[0];
+let words: [int; 4];
+let w0 = words[0];
+`)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	want := map[string]ast.Type{
+		"s":     ast.StringType,
+		"l":     ast.ByteListType,
+		"n":     ast.IntType,
+		"b":     ast.BoolType,
+		"t":     ast.StringType,
+		"r":     ast.IntType,
+		"big":   ast.LongType,
+		"w":     ast.LongType,
+		"c":     ast.BoolType,
+		"u":     ast.UintType,
+		"v":     ast.UintType,
+		"z":     ast.UintType,
+		"m":     ast.LongType,
+		"e":     ast.StringType,
+		"done":  ast.BoolType,
+		"o":     ast.BoolType,
+		"ints":  ast.IntListType,
+		"i0":    ast.IntType,
+		"longs": ast.LongListType,
+		"l0":    ast.LongType,
+		"w0":    ast.IntType,
+	}
+	for _, stmt := range prog.Body {
+		decl, ok := stmt.(ast.VarDeclarationStmt)
+		if !ok || decl.AssignedValue == nil {
+			continue
+		}
+		if got := decl.AssignedValue.StaticType(); got != want[decl.Identifier] {
+			t.Errorf("%s: type %v, want %v", decl.Identifier, got, want[decl.Identifier])
+		}
+	}
+}
+
+func TestFoldsConstants(t *testing.T) {
+	prog, errs := check(t, `const SIZE = 4 * 8;
+const IRQ = SIZE / 32;
+let buf = list(SIZE + 1);
+let n = SIZE - IRQ;
+inter IRQ { print(n); }
+trap IRQ + 1 { print(trapCause()); }
+`)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	buf := prog.Body[2].(ast.VarDeclarationStmt).AssignedValue.(ast.ListEx)
+	if buf.Size != 33 || buf.SizeExpr != nil {
+		t.Errorf("list size %d (%v), want 33", buf.Size, buf.SizeExpr)
+	}
+	n := prog.Body[3].(ast.VarDeclarationStmt).AssignedValue.(ast.BinaryExpr)
+	if _, ok := n.Left.(ast.NumberExpr); !ok {
+		t.Errorf("constant use %T, want a number literal", n.Left)
+	}
+	if inter := prog.Body[4].(ast.InterruptionStmt); inter.IrqNumber != 1 {
+		t.Errorf("interruption number %d, want 1", inter.IrqNumber)
+	}
+	if trap := prog.Body[5].(ast.TrapStmt); trap.Cause != 2 || trap.CauseExpr != nil {
+		t.Errorf("trap number %d (%v), want 2", trap.Cause, trap.CauseExpr)
+	}
+}
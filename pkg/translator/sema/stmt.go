@@ -0,0 +1,210 @@
+package sema
+
+import (
+	"github.com/awesoma31/csa-lab4/pkg/translator/ast"
+)
+
+func (c *checker) stmt(stmt ast.Stmt) ast.Stmt {
+	defer c.enter(stmt)()
+	switch s := stmt.(type) {
+	case ast.VarDeclarationStmt:
+		return c.varDecl(s)
+	case ast.ExpressionStmt:
+		s.Expression = c.expr(s.Expression)
+		return s
+	case ast.BlockStmt:
+		return c.block(s)
+	case ast.PrintStmt:
+		s.Argument = c.expr(s.Argument)
+		if ast.KindOf(s.Argument.StaticType()) == ast.TypeList {
+			c.errorAt(s.Argument, "cannot print %s", typeName(s.Argument.StaticType()))
+		}
+		return s
+	case ast.IfStmt:
+		s.Condition = c.condition(s.Condition)
+		s.Consequent = c.body(s.Consequent)
+		if s.Alternate != nil {
+			s.Alternate = c.body(s.Alternate)
+		}
+		return s
+	case ast.MatchStmt:
+		return c.match(s)
+	case ast.ConstDeclarationStmt:
+		return c.constDecl(s)
+	case ast.WhileStmt:
+		s.Condition = c.condition(s.Condition)
+		s.Body = c.body(s.Body)
+		return s
+	case ast.ForStmt:
+		c.pushScope()
+		if s.Init != nil {
+			s.Init = c.stmt(s.Init)
+		}
+		s.Condition = c.condition(s.Condition)
+		if s.Post != nil {
+			s.Post = c.expr(s.Post)
+		}
+		s.Body = c.body(s.Body)
+		c.popScope()
+		return s
+	case ast.ForeachStmt:
+		return c.foreach(s)
+	case ast.InterruptionStmt:
+		if s.IrqExpr != nil {
+			irqN, _ := c.constant(s.IrqExpr, "interruption number")
+			s.IrqNumber, s.IrqExpr = int(irqN), nil
+		}
+		s.Body = c.body(s.Body)
+		return s
+	case ast.TrapStmt:
+		if s.CauseExpr != nil {
+			cause, _ := c.constant(s.CauseExpr, "trap number")
+			s.Cause, s.CauseExpr = int(cause), nil
+		}
+		s.Body = c.body(s.Body)
+		return s
+	case ast.FreeStmt:
+		s.Target = c.expr(s.Target)
+		if t := s.Target.StaticType(); t != nil && ast.KindOf(t) != ast.TypeList {
+			c.errorAt(s.Target, "cannot free %s", typeName(t))
+		}
+		return s
+	case ast.ReturnStmt:
+		if s.Expr != nil {
+			s.Expr = c.expr(s.Expr)
+			if c.inFn && !isWord(s.Expr.StaticType()) {
+				c.addError("function must return int, got %s", typeName(s.Expr.StaticType()))
+			}
+		}
+		return s
+	default:
+		// break, continue, intOn, intOff have nothing to check;
+		// misplaced statements are reported by the code generator
+		return s
+	}
+}
+
+// varDecl checks `let x = v;` and `let x: T [= v];`. Without a type annotation the
+// type of x is the type of v, with one v must fit T and may be omitted (x is zero then).
+func (c *checker) varDecl(s ast.VarDeclarationStmt) ast.Stmt {
+	if s.AssignedValue != nil {
+		s.AssignedValue = c.expr(s.AssignedValue)
+	}
+
+	switch {
+	case s.ExplicitType == nil && s.AssignedValue == nil:
+		c.addError("variable '%s' must be initialized", s.Identifier)
+	case s.ExplicitType == nil:
+		c.declare(s.Identifier, s.AssignedValue.StaticType())
+		return s
+	case s.AssignedValue == nil:
+		if ast.IsUnsized(s.ExplicitType) {
+			c.addError("variable '%s' must be initialized", s.Identifier)
+		}
+	case ast.KindOf(s.ExplicitType) == ast.TypeList && !ast.IsUnsized(s.ExplicitType):
+		c.addError("array '%s' cannot have an initializer", s.Identifier)
+	default:
+		s.AssignedValue = allocOf(s.ExplicitType, uintLiteral(s.ExplicitType, s.AssignedValue))
+		if value := s.AssignedValue.StaticType(); !assignable(s.ExplicitType, value) {
+			c.addError("cannot use %s as %s in declaration of '%s'", typeName(value), typeName(s.ExplicitType), s.Identifier)
+		}
+	}
+	c.declare(s.Identifier, s.ExplicitType)
+	return s
+}
+
+// match checks a match statement, an arm value may appear only once.
+func (c *checker) match(s ast.MatchStmt) ast.Stmt {
+	s.Subject = c.word(s.Subject, "match value")
+	seen := make(map[int32]bool)
+	arms := make([]ast.MatchArm, len(s.Arms))
+	for i, arm := range s.Arms {
+		for _, v := range arm.Values {
+			if seen[v] {
+				c.errorAt(arm, "duplicate match value %d", v)
+			}
+			seen[v] = true
+		}
+		arm.Body = c.block(arm.Body)
+		arms[i] = arm
+	}
+	s.Arms = arms
+	if s.Default != nil {
+		s.Default = c.body(s.Default)
+	}
+	return s
+}
+
+// block checks statements of a block in a new scope.
+func (c *checker) block(b ast.BlockStmt) ast.BlockStmt {
+	c.pushScope()
+	b.Body = c.stmts(b.Body)
+	c.popScope()
+	return b
+}
+
+// body checks the body of a compound statement, a block opens its own scope.
+func (c *checker) body(stmt ast.Stmt) ast.Stmt {
+	if b, ok := stmt.(ast.BlockStmt); ok {
+		return c.block(b)
+	}
+	return c.stmt(stmt)
+}
+
+func (c *checker) stmts(stmts []ast.Stmt) []ast.Stmt {
+	if stmts == nil {
+		return nil
+	}
+	out := make([]ast.Stmt, len(stmts))
+	for i, stmt := range stmts {
+		out[i] = c.stmt(stmt)
+	}
+	return out
+}
+
+// foreach checks `for x in a..b {}` and `for x in iterable {}`, x is an int,
+// or a long when iterating over a list of longs.
+func (c *checker) foreach(s ast.ForeachStmt) ast.Stmt {
+	c.pushScope()
+	if r, ok := s.Iterable.(ast.RangeExpr); ok {
+		r.Lower = c.word(r.Lower, "range bound")
+		r.Upper = c.word(r.Upper, "range bound")
+		r.Type = ast.IntType
+		s.Iterable = r
+	} else {
+		s.Iterable = c.expr(s.Iterable)
+		switch ast.KindOf(s.Iterable.StaticType()) {
+		case ast.TypeList, ast.TypeString, ast.TypeUnknown:
+		default:
+			c.errorAt(s.Iterable, "cannot iterate over %s", typeName(s.Iterable.StaticType()))
+		}
+	}
+	c.declare(s.Value, elementType(s.Iterable.StaticType()))
+	s.Body = c.block(ast.BlockStmt{Body: s.Body}).Body
+	c.popScope()
+	return s
+}
+
+func (c *checker) declareFunction(fn ast.FunctionDeclarationStmt) {
+	defer c.enter(fn)()
+	if _, found := c.functions[fn.Name]; found {
+		c.addError("Function '%s' already declared", fn.Name)
+		return
+	}
+	c.functions[fn.Name] = fn
+}
+
+// function checks a function body, parameters and the result are ints.
+func (c *checker) function(fn ast.FunctionDeclarationStmt) ast.Stmt {
+	defer c.enter(fn)()
+	c.inFn = true
+	c.pushScope()
+	for _, param := range fn.Parameters {
+		c.pos = param.Pos
+		c.declare(param.Name, ast.IntType)
+	}
+	fn.Body = c.stmts(fn.Body)
+	c.popScope()
+	c.inFn = false
+	return fn
+}